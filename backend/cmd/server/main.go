@@ -4,13 +4,22 @@ import (
 	"context"
 	"dev-compass/internal/application"
 	"dev-compass/internal/domain/entities"
+	"dev-compass/internal/domain/ports"
 	"dev-compass/internal/infrastructure/config"
+	"dev-compass/internal/infrastructure/http/handlers/apis"
 	"dev-compass/internal/infrastructure/http/handlers/catalog"
+	"dev-compass/internal/infrastructure/http/handlers/discovery"
 	"dev-compass/internal/infrastructure/http/handlers/environments"
+	"dev-compass/internal/infrastructure/http/handlers/groups"
+	"dev-compass/internal/infrastructure/http/handlers/locations"
+	"dev-compass/internal/infrastructure/http/handlers/schemas"
+	"dev-compass/internal/infrastructure/http/handlers/systems"
 	"dev-compass/internal/infrastructure/http/handlers/techdocs"
+	"dev-compass/internal/infrastructure/http/handlers/webhooks"
 	"dev-compass/internal/infrastructure/http/middlewares"
 	"dev-compass/internal/infrastructure/http/routes"
 	"dev-compass/internal/infrastructure/persistence/postgres"
+	"dev-compass/internal/infrastructure/persistence/sqlite"
 	"flag"
 	"fmt"
 	"github.com/gin-gonic/gin"
@@ -20,58 +29,63 @@ import (
 
 func main() {
 	// --- Command-line flag for seeding ---
-	seed := flag.Bool("seed", true, "Set to true to seed the database with mock data")
+	seed := flag.Bool("seed", true, "Set to true to run discovery as soon as the server starts")
 	flag.Parse()
 
 	// --- Database Connection ---
 	log.Println("INFO: Initializing database connection...")
-	err := godotenv.Load(".env")
-	if err != nil {
-		fmt.Println(err.Error())
-		return
+	// The environment alone is enough to run standalone, e.g. with DB_DRIVER=sqlite.
+	if err := godotenv.Load(".env"); err != nil {
+		log.Printf("INFO: Not loading .env: %v", err)
 	}
 
 	cfg := config.Load()
 
-	conn, err := postgres.ConnectDB(cfg)
+	entityRepo, locationRepo, relationRepo, err := openRepositories(cfg)
 	if err != nil {
-		fmt.Println(err.Error())
-		return
+		log.Fatalf("FATAL: Failed to open the database: %v", err)
 	}
 
-	log.Println("INFO: Running database migrations...")
-	if err := conn.AutoMigrate(&entities.Entity{}); err != nil {
-		log.Fatalf("FATAL: Failed to run database migrations: %v", err)
-	}
-
-	// --- Repository Initialization ---
-	entityRepo := postgres.NewEntityRepository(conn)
-
-	// --- Data Ingestion (conditional) ---
-	if *seed {
-		log.Println("INFO: --seed flag detected. Starting GitLab discovery...")
-		discoverySvc, err := application.NewDiscoveryService(cfg, entityRepo)
-		if err != nil {
-			log.Fatalf("FATAL: Failed to create Discovery Service: %v", err)
-		}
-		if err := discoverySvc.RunDiscovery(context.Background()); err != nil {
-			log.Fatalf("FATAL: GitLab discovery process failed: %v", err)
-		}
+	// --- Data Ingestion (scheduled) ---
+	var discoveryScheduler *application.DiscoveryScheduler
+	var projectRefresher *application.ProjectRefresher
+	// Every service that reconciles the catalog takes the same lock.
+	catalogLock := application.NewCatalogLock()
+	discoverySvc, err := application.NewDiscoveryService(cfg, entityRepo, locationRepo, relationRepo, catalogLock)
+	if err != nil {
+		log.Printf("WARN: Discovery is disabled: %v", err)
+	} else {
+		discoveryScheduler = application.NewDiscoveryScheduler(discoverySvc, cfg.Discovery)
+		discoveryScheduler.Start(context.Background(), *seed)
+		projectRefresher = application.NewProjectRefresher(discoverySvc)
+		projectRefresher.Start(context.Background())
 	}
 
 	// --- Service & Handler Initialization ---
-	catalogSvc := application.NewCatalogService(entityRepo)
+	catalogSvc := application.NewCatalogService(entityRepo, relationRepo)
 	environmentSvc := application.NewEnvironmentService(entityRepo)
-	catalogHandler := catalog.NewHandler(catalogSvc)
+	locationSvc := application.NewLocationService(cfg, locationRepo, entityRepo, relationRepo, catalogLock)
+	apiSvc := application.NewAPIService(entityRepo, relationRepo)
+	systemSvc := application.NewSystemService(entityRepo, relationRepo)
+	ownerSvc := application.NewOwnerService(entityRepo, relationRepo)
+	manualEntitySvc := application.NewManualEntityService(cfg, entityRepo, relationRepo, catalogLock)
+	catalogHandler := catalog.NewHandler(catalogSvc, manualEntitySvc)
 	environmentHandler := environments.NewHandler(environmentSvc)
-	techdocsHandler := techdocs.NewHandler()
+	techdocsHandler := techdocs.NewHandler(catalogSvc, application.NewTechDocsReader(cfg))
+	discoveryHandler := discovery.NewHandler(discoveryScheduler)
+	webhooksHandler := webhooks.NewHandler(discoverySvc, projectRefresher, cfg.GitLab.WebhookSecret)
+	locationsHandler := locations.NewHandler(locationSvc)
+	apisHandler := apis.NewHandler(apiSvc)
+	systemsHandler := systems.NewHandler(systemSvc)
+	groupsHandler := groups.NewHandler(ownerSvc)
+	schemasHandler := schemas.NewHandler()
 
 	// --- Router Setup ---
 	gin.SetMode(cfg.App.GinMode)
 	router := gin.New()
 
 	router.Use(middlewares.Cors())
-	routes.SetupRoutes(router, catalogHandler, techdocsHandler, environmentHandler)
+	routes.SetupRoutes(router, catalogHandler, techdocsHandler, environmentHandler, discoveryHandler, webhooksHandler, locationsHandler, apisHandler, systemsHandler, groupsHandler, schemasHandler)
 
 	// --- Server Start ---
 	log.Println("INFO: Server is starting on port 8080...")
@@ -79,3 +93,40 @@ func main() {
 		log.Fatalf("FATAL: Failed to start server: %v", err)
 	}
 }
+
+// openRepositories connects to the database selected by DB_DRIVER, migrates it
+// and returns its repositories.
+func openRepositories(cfg *config.Config) (ports.EntityRepository, ports.LocationRepository, ports.RelationRepository, error) {
+	if cfg.DB.Driver == config.DriverSQLite {
+		log.Printf("INFO: Using the SQLite database %s", cfg.DB.Path)
+		conn, err := sqlite.ConnectDB(cfg)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		log.Println("INFO: Running database migrations...")
+		if err := sqlite.Migrate(conn); err != nil {
+			return nil, nil, nil, fmt.Errorf("failed to run database migrations: %w", err)
+		}
+		return sqlite.NewEntityRepository(conn, cfg.Catalog.MaxRevisions), sqlite.NewLocationRepository(conn), sqlite.NewRelationRepository(conn), nil
+	}
+
+	conn, err := postgres.ConnectDB(cfg)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	log.Println("INFO: Running database migrations...")
+	if err := postgres.MigrateEntityRefs(conn); err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to run database migrations: %w", err)
+	}
+	if err := postgres.MigrateEntityUIDs(conn); err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to run database migrations: %w", err)
+	}
+	if err := conn.AutoMigrate(&entities.Entity{}, &entities.Location{}, &entities.EntityRelation{}, &entities.EntityRevision{}); err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to run database migrations: %w", err)
+	}
+	if err := postgres.MigrateSearch(conn); err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to run database migrations: %w", err)
+	}
+	return postgres.NewEntityRepository(conn, cfg.Catalog.MaxRevisions), postgres.NewLocationRepository(conn), postgres.NewRelationRepository(conn), nil
+}
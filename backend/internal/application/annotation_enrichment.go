@@ -0,0 +1,157 @@
+package application
+
+import (
+	"context"
+	"dev-compass/internal/domain/entities"
+	"dev-compass/internal/infrastructure/config"
+	"encoding/json"
+	"errors"
+	"log"
+	"path"
+	"strings"
+)
+
+// Annotations that drive enrichment, whatever provider an entity comes from. They
+// let a hand-written entity point at the project its code actually lives in.
+const (
+	// gitlabProjectSlugAnnotation is the path of the GitLab project of a component, e.g. "group/project".
+	gitlabProjectSlugAnnotation = "gitlab.com/project-slug"
+	// techDocsRefAnnotation points to the documentation of a component, e.g. "dir:./docs".
+	techDocsRefAnnotation = "devcompass.io/techdocs-ref"
+	// deploymentEnvironmentsAnnotation is a comma-separated list of the GitLab environments a component is deployed to.
+	deploymentEnvironmentsAnnotation = "devcompass.io/deployment-environments"
+)
+
+// Status item types reported while enriching entities from their annotations.
+const (
+	projectUnavailableStatus = "devcompass.io/project-unavailable"
+	invalidAnnotationStatus  = "devcompass.io/invalid-annotation"
+)
+
+// defaultDeploymentEnvironments are the GitLab environments deployments are read
+// from when a component does not list its own.
+var defaultDeploymentEnvironments = []string{"wg_adquirencia_prod", "wg_adquirencia_uat", "wg_adquirencia_qa", "wg_adquirencia_dev"}
+
+// deploymentEnvironments returns the environments listed in the annotations of an entity, or the default ones.
+func deploymentEnvironments(annotations map[string]string) []string {
+	var environments []string
+	for _, name := range strings.Split(annotations[deploymentEnvironmentsAnnotation], ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			environments = append(environments, name)
+		}
+	}
+	if len(environments) == 0 {
+		return defaultDeploymentEnvironments
+	}
+	return environments
+}
+
+// techDocsRef returns the docs directory an entity of any kind points to with its
+// techdocs-ref annotation, or an empty string when it has none. An annotation that
+// can't be used is reported to status.
+func techDocsRef(annotations map[string]string, status *statusCollector) string {
+	ref, ok := annotations[techDocsRefAnnotation]
+	if !ok {
+		return ""
+	}
+	dir, err := techDocsRefDir(ref)
+	if err != nil {
+		status.add("warning", invalidAnnotationStatus, "%s %q %v", techDocsRefAnnotation, ref, err)
+		return ""
+	}
+	return dir
+}
+
+// techDocsRefDir returns the directory of a techdocs-ref annotation. Only "dir:"
+// references, relative to the repository root, are supported.
+func techDocsRefDir(ref string) (string, error) {
+	dir, found := strings.CutPrefix(ref, "dir:")
+	if !found {
+		return "", errors.New("is not supported, only dir: references are")
+	}
+	dir = path.Clean(strings.TrimSpace(dir))
+	if path.IsAbs(dir) || dir == ".." || strings.HasPrefix(dir, "../") {
+		return "", errors.New("must point inside the repository")
+	}
+	return dir, nil
+}
+
+// annotationEnricher enriches the components of every provider that point at a
+// GitLab project through their annotations. Components discovered in GitLab are
+// enriched by the GitLab provider itself.
+type annotationEnricher struct {
+	gitlab *GitLabProvider // nil when GitLab is not configured
+}
+
+// newAnnotationEnricher creates an annotationEnricher. gitlab is the provider used
+// by discovery, if any; otherwise a client is created when a token is configured.
+func newAnnotationEnricher(cfg *config.Config, gitlab *GitLabProvider) *annotationEnricher {
+	if gitlab == nil && cfg.GitLab.Token != "" {
+		provider, err := NewGitLabProvider(cfg, nil)
+		if err != nil {
+			log.Printf("WARN: Could not create the GitLab client, %s annotations will be ignored: %v", gitlabProjectSlugAnnotation, err)
+		} else {
+			gitlab = provider
+		}
+	}
+	return &annotationEnricher{gitlab: gitlab}
+}
+
+// enrich enriches the entities of a source from their annotations. Problems are
+// added to the status of each entity.
+func (e *annotationEnricher) enrich(ctx context.Context, source string, discovered []*entities.Entity) {
+	fromGitLab := strings.HasPrefix(source, "gitlab:")
+	for _, entity := range discovered {
+		slug, _ := entity.Metadata.Annotations[gitlabProjectSlugAnnotation].(string)
+		if slug == "" {
+			continue
+		}
+
+		status := &statusCollector{}
+		if entity.Kind != "Component" {
+			// Only components have a spec to hold what the project tells about them.
+			status.add("warning", invalidAnnotationStatus, "%s is only used on Components, it is ignored on a %s", gitlabProjectSlugAnnotation, entity.Kind)
+			appendStatus(entity, status.items)
+			continue
+		}
+		if fromGitLab {
+			continue
+		}
+		if e.gitlab == nil {
+			status.add("warning", projectUnavailableStatus, "%s is set but GitLab is not configured", gitlabProjectSlugAnnotation)
+			appendStatus(entity, status.items)
+			continue
+		}
+
+		var spec entities.ComponentSpec
+		if err := json.Unmarshal(entity.Spec, &spec); err != nil {
+			log.Printf("ERROR: could not unmarshal spec for entity %s: %v", entity.Ref, err)
+			continue
+		}
+		annotations := make(map[string]string)
+		for key, value := range entity.Metadata.Annotations {
+			if s, ok := value.(string); ok {
+				annotations[key] = s
+			}
+		}
+		e.gitlab.enrichFromProject(ctx, slug, &spec, deploymentEnvironments(annotations), status)
+		specJSON, err := json.Marshal(spec)
+		if err != nil {
+			log.Printf("ERROR: could not marshal enriched spec for entity %s: %v", entity.Ref, err)
+			continue
+		}
+		entity.Spec = specJSON
+		appendStatus(entity, status.items)
+	}
+}
+
+// appendStatus adds status items to those already recorded on an entity.
+func appendStatus(entity *entities.Entity, items []entities.StatusItem) {
+	if len(items) == 0 {
+		return
+	}
+	if entity.Status == nil {
+		entity.Status = &entities.EntityStatus{}
+	}
+	entity.Status.Items = append(entity.Status.Items, items...)
+}
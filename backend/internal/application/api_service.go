@@ -0,0 +1,73 @@
+package application
+
+import (
+	"dev-compass/internal/domain/entities"
+	"dev-compass/internal/domain/ports"
+	"encoding/json"
+	"sort"
+)
+
+// APIDetails is an API entity together with the components that provide and consume it.
+type APIDetails struct {
+	entities.Entity
+	Providers []string `json:"providers"`
+	Consumers []string `json:"consumers"`
+}
+
+// APIService provides services related to API entities.
+type APIService struct {
+	repo      ports.EntityRepository
+	relations ports.RelationRepository
+}
+
+// NewAPIService creates a new APIService.
+func NewAPIService(repo ports.EntityRepository, relations ports.RelationRepository) *APIService {
+	return &APIService{repo: repo, relations: relations}
+}
+
+// GetAPI returns an API with its providers and consumers, found through the
+// apiProvidedBy and apiConsumedBy relations of the API.
+func (s *APIService) GetAPI(namespace, name string) (*APIDetails, error) {
+	api, err := s.repo.FindByRef(entities.EntityRef("API", namespace, name))
+	if err != nil {
+		return nil, err
+	}
+
+	relations, err := s.relations.FindBySource(api.Ref)
+	if err != nil {
+		return nil, err
+	}
+
+	details := &APIDetails{Entity: *api, Providers: []string{}, Consumers: []string{}}
+	seen := make(map[entities.EntityRelation]bool)
+	for _, relation := range relations {
+		relation.OriginRef = ""
+		if seen[relation] {
+			continue
+		}
+		seen[relation] = true
+		switch relation.Type {
+		case entities.RelationAPIProvidedBy:
+			details.Providers = append(details.Providers, relation.TargetRef)
+		case entities.RelationAPIConsumedBy:
+			details.Consumers = append(details.Consumers, relation.TargetRef)
+		}
+	}
+	sort.Strings(details.Providers)
+	sort.Strings(details.Consumers)
+	return details, nil
+}
+
+// GetDefinition returns the type and raw definition of an API.
+func (s *APIService) GetDefinition(namespace, name string) (string, string, error) {
+	api, err := s.repo.FindByRef(entities.EntityRef("API", namespace, name))
+	if err != nil {
+		return "", "", err
+	}
+
+	var spec entities.APISpec
+	if err := json.Unmarshal(api.Spec, &spec); err != nil {
+		return "", "", err
+	}
+	return spec.Type, spec.Definition, nil
+}
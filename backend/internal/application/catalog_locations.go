@@ -0,0 +1,338 @@
+package application
+
+import (
+	"bytes"
+	"context"
+	"dev-compass/internal/domain/entities"
+	"dev-compass/internal/infrastructure/config"
+	"errors"
+	"fmt"
+	"github.com/mitchellh/mapstructure"
+	"io"
+	"io/fs"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// maxLocationDepth limits how deep Location entities may reference each other.
+const maxLocationDepth = 10
+
+// maxCatalogFileSize limits how much of a remote catalog file is read.
+const maxCatalogFileSize = 5 << 20
+
+// readLocalFile reads a catalog file, or a file a placeholder points to, from disk.
+// Like a remote file, it is read up to maxCatalogFileSize bytes.
+func readLocalFile(name string) ([]byte, error) {
+	file, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, maxCatalogFileSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxCatalogFileSize {
+		return nil, fmt.Errorf("%s is larger than %d bytes", name, maxCatalogFileSize)
+	}
+	return data, nil
+}
+
+// catalogReader reads the catalog file at a target. A missing file is reported
+// with an error matching fs.ErrNotExist.
+type catalogReader func(ctx context.Context, target string) ([]byte, error)
+
+// catalogTree collects the documents of one or more catalog files and, recursively,
+// of every file referenced by the Location entities in them. Relative targets are
+// resolved against the file that references them. Each file is read at most
+// once, which also cuts reference cycles.
+type catalogTree struct {
+	read      catalogReader
+	visited   map[string]bool
+	documents []yamlEntity
+}
+
+// newCatalogTree creates an empty catalogTree that reads referenced files with read.
+func newCatalogTree(read catalogReader) *catalogTree {
+	return &catalogTree{read: read, visited: make(map[string]bool)}
+}
+
+// readCatalogTree decodes the catalog file at root along with every location it references.
+func readCatalogTree(ctx context.Context, root string, content []byte, read catalogReader) ([]yamlEntity, error) {
+	tree := newCatalogTree(read)
+	if err := tree.add(ctx, root, content); err != nil {
+		return nil, err
+	}
+	return tree.documents, nil
+}
+
+// add decodes a catalog file already read from target and follows its locations.
+func (t *catalogTree) add(ctx context.Context, target string, content []byte) error {
+	if t.visited[target] {
+		return nil
+	}
+	t.visited[target] = true
+	return t.visit(ctx, target, content, 0)
+}
+
+func (t *catalogTree) visit(ctx context.Context, target string, content []byte, depth int) error {
+	decoded, err := decodeCatalogDocuments(bytes.NewReader(content))
+	if err != nil {
+		return fmt.Errorf("failed to parse YAML from %s: %w", target, err)
+	}
+
+	for _, document := range decoded {
+		document.location = target
+		t.documents = append(t.documents, document)
+		if document.Kind != "Location" {
+			continue
+		}
+
+		var spec entities.LocationSpec
+		if err := mapstructure.Decode(document.Spec, &spec); err != nil {
+			return fmt.Errorf("failed to decode Location spec for %s: %w", document.Metadata.Name, err)
+		}
+		if depth >= maxLocationDepth {
+			return fmt.Errorf("location %s is nested more than %d levels deep", document.Metadata.Name, maxLocationDepth)
+		}
+
+		for _, ref := range locationTargets(spec) {
+			next := resolveLocationTarget(target, ref)
+			if t.visited[next] {
+				log.Printf("DEBUG: Location %s was already read, skipping it.", next)
+				continue
+			}
+			t.visited[next] = true
+
+			nextContent, err := t.read(ctx, next)
+			if err != nil {
+				if errors.Is(err, fs.ErrNotExist) && spec.Presence == "optional" {
+					log.Printf("DEBUG: Optional location %s does not exist, skipping it.", next)
+					continue
+				}
+				return fmt.Errorf("failed to read location %s referenced by %s: %w", next, target, err)
+			}
+			if err := t.visit(ctx, next, nextContent, depth+1); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// locationTargets returns every target of a Location spec.
+func locationTargets(spec entities.LocationSpec) []string {
+	var targets []string
+	if spec.Target != "" {
+		targets = append(targets, spec.Target)
+	}
+	for _, target := range spec.Targets {
+		if target != "" {
+			targets = append(targets, target)
+		}
+	}
+	return targets
+}
+
+// resolveLocationTarget resolves a Location target against the file that references it.
+func resolveLocationTarget(base, target string) string {
+	if isRemoteTarget(target) {
+		return target
+	}
+	if isRemoteTarget(base) {
+		baseURL, err := url.Parse(base)
+		if err == nil {
+			if ref, err := url.Parse(target); err == nil {
+				return baseURL.ResolveReference(ref).String()
+			}
+		}
+	}
+	if path.IsAbs(target) {
+		return path.Clean(target)
+	}
+	return path.Join(path.Dir(base), target)
+}
+
+// isRemoteTarget reports whether a target is an HTTP(S) URL rather than a file path.
+func isRemoteTarget(target string) bool {
+	return strings.HasPrefix(target, "https://") || strings.HasPrefix(target, "http://")
+}
+
+// repositoryPath validates a target read from inside a repository, making it
+// relative to the repository root. Targets must not leave the repository.
+func repositoryPath(target string) (string, error) {
+	cleaned := path.Clean(target)
+	if cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return "", fmt.Errorf("location %s points outside of the repository", target)
+	}
+	return strings.TrimPrefix(cleaned, "/"), nil
+}
+
+// errHostNotAllowed is returned when a catalog file is on a host that may not be read.
+var errHostNotAllowed = errors.New("host is not allowed")
+
+// urlReader reads catalog files over HTTP(S). Requests to the GitLab and GitHub
+// hosts carry the configured tokens, so private repositories can be registered.
+// Since anyone may register a location, other hosts must be public, and listed
+// in the configuration when it restricts the hosts.
+type urlReader struct {
+	client  *http.Client
+	auth    map[string]func(req *http.Request) // By host name
+	allowed map[string]bool
+}
+
+// newURLReader creates a urlReader using the tokens and allowed hosts of the configuration.
+func newURLReader(cfg *config.Config) *urlReader {
+	r := &urlReader{auth: make(map[string]func(req *http.Request)), allowed: make(map[string]bool)}
+	for _, host := range cfg.Discovery.AllowedHosts {
+		r.allowed[strings.ToLower(host)] = true
+	}
+	if cfg.GitLab.Token != "" {
+		r.auth["gitlab.com"] = func(req *http.Request) {
+			req.Header.Set("PRIVATE-TOKEN", cfg.GitLab.Token)
+		}
+	}
+	if cfg.GitHub.Token != "" {
+		setToken := func(req *http.Request) {
+			req.Header.Set("Authorization", "Bearer "+cfg.GitHub.Token)
+		}
+		r.auth["raw.githubusercontent.com"] = setToken
+		if apiURL, err := url.Parse(cfg.GitHub.APIURL); err == nil && apiURL.Hostname() != "" {
+			r.auth[strings.ToLower(apiURL.Hostname())] = setToken
+		}
+	}
+
+	dialer := &net.Dialer{Timeout: 10 * time.Second}
+	// The address is checked once resolved, so a host can't pass the check with a
+	// public address and then be connected to at a private one.
+	publicDialer := &net.Dialer{
+		Timeout: 10 * time.Second,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !isPublicAddress(ip) {
+				return fmt.Errorf("%w: %s is not a public address", errHostNotAllowed, host)
+			}
+			return nil
+		},
+	}
+	r.client = &http.Client{
+		Timeout: 30 * time.Second,
+		// No proxy is used, since the addresses checked must be the ones connected to.
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, network, address string) (net.Conn, error) {
+				host, _, err := net.SplitHostPort(address)
+				if err != nil {
+					return nil, err
+				}
+				if r.trusted(host) {
+					return dialer.DialContext(ctx, network, address)
+				}
+				return publicDialer.DialContext(ctx, network, address)
+			},
+			TLSHandshakeTimeout: 10 * time.Second,
+			MaxIdleConns:        10,
+			IdleConnTimeout:     90 * time.Second,
+		},
+		// Tokens must never follow a redirect to another host.
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= 10 {
+				return errors.New("stopped after 10 redirects")
+			}
+			if err := r.check(req.URL); err != nil {
+				return err
+			}
+			req.Header.Del("PRIVATE-TOKEN")
+			req.Header.Del("Authorization")
+			r.authorize(req)
+			return nil
+		},
+	}
+	return r
+}
+
+// check returns an error matching errHostNotAllowed when a URL is not on an
+// allowed host. Whether its address is public is only known once connecting.
+func (r *urlReader) check(target *url.URL) error {
+	if len(r.allowed) > 0 && !r.trusted(target.Hostname()) {
+		return fmt.Errorf("%w: %s", errHostNotAllowed, target.Hostname())
+	}
+	return nil
+}
+
+// trusted reports whether a host is allowed in the configuration or is a GitLab
+// or GitHub host, which may then have a private address.
+func (r *urlReader) trusted(host string) bool {
+	host = strings.ToLower(host)
+	return r.allowed[host] || r.auth[host] != nil
+}
+
+// isPublicAddress reports whether an IP address may be reached on the internet.
+func isPublicAddress(ip net.IP) bool {
+	return !ip.IsPrivate() && !ip.IsLoopback() && !ip.IsLinkLocalUnicast() && !ip.IsLinkLocalMulticast() &&
+		!ip.IsInterfaceLocalMulticast() && !ip.IsMulticast() && !ip.IsUnspecified()
+}
+
+// read fetches a remote catalog file.
+func (r *urlReader) read(ctx context.Context, target string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.check(req.URL); err != nil {
+		return nil, err
+	}
+	r.authorize(req)
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("%s: %w", target, fs.ErrNotExist)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s reading %s", resp.Status, target)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxCatalogFileSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxCatalogFileSize {
+		return nil, fmt.Errorf("%s is larger than %d bytes", target, maxCatalogFileSize)
+	}
+	return data, nil
+}
+
+// authorize adds the token of the request host, if any. Tokens are only sent over HTTPS.
+func (r *urlReader) authorize(req *http.Request) {
+	if req.URL.Scheme != "https" {
+		return
+	}
+	if auth, ok := r.auth[strings.ToLower(req.URL.Hostname())]; ok {
+		auth(req)
+	}
+}
+
+// withRemoteTargets returns a catalogReader that reads URLs with urls and any other target with local.
+func withRemoteTargets(urls *urlReader, local catalogReader) catalogReader {
+	return func(ctx context.Context, target string) ([]byte, error) {
+		if isRemoteTarget(target) {
+			return urls.read(ctx, target)
+		}
+		return local(ctx, target)
+	}
+}
@@ -3,19 +3,77 @@ package application
 import (
 	"dev-compass/internal/domain/entities"
 	"dev-compass/internal/domain/ports"
+	"sort"
 )
 
 // CatalogService provides entity-related services.
 type CatalogService struct {
-	repo ports.EntityRepository
+	repo      ports.EntityRepository
+	relations ports.RelationRepository
+}
+
+// RelationView is a relation of an entity, seen from that entity.
+type RelationView struct {
+	Type   string `json:"type"`
+	Target string `json:"target"`
 }
 
 // NewCatalogService creates a new CatalogService.
-func NewCatalogService(repo ports.EntityRepository) *CatalogService {
-	return &CatalogService{repo: repo}
+func NewCatalogService(repo ports.EntityRepository, relations ports.RelationRepository) *CatalogService {
+	return &CatalogService{repo: repo, relations: relations}
+}
+
+// QueryEntities returns the page of entities selected by a query.
+func (s *CatalogService) QueryEntities(query ports.EntityQuery) (*ports.EntityPage, error) {
+	return s.repo.Query(query)
+}
+
+// GetFacets counts the distinct values of each facet across the entities matching a query.
+func (s *CatalogService) GetFacets(query ports.EntityQuery, facets []ports.Facet) (map[string][]ports.FacetCount, error) {
+	return s.repo.Facets(query, facets)
 }
 
-// GetAllEntities returns all entities from the repository.
-func (s *CatalogService) GetAllEntities(search, tag string) ([]entities.Entity, error) {
-	return s.repo.FindAll(search, tag)
+// Search returns the entities matching a full-text search among those selected by the filters.
+func (s *CatalogService) Search(text string, filters ports.EntityQuery) ([]ports.SearchResult, error) {
+	return s.repo.Search(text, filters)
+}
+
+// GetEntity returns a single entity by kind, namespace and name. It returns
+// ports.ErrNotFound when the entity does not exist.
+func (s *CatalogService) GetEntity(kind, namespace, name string) (*entities.Entity, error) {
+	return s.repo.FindByRef(entities.EntityRef(kind, namespace, name))
+}
+
+// GetRelations returns the relations of an entity, in both directions: a
+// component that depends on a resource shows up as dependencyOf on the resource.
+// relationType optionally keeps only the relations of one type.
+func (s *CatalogService) GetRelations(kind, namespace, name, relationType string) ([]RelationView, error) {
+	entity, err := s.repo.FindByRef(entities.EntityRef(kind, namespace, name))
+	if err != nil {
+		return nil, err
+	}
+
+	stored, err := s.relations.FindBySource(entity.Ref)
+	if err != nil {
+		return nil, err
+	}
+
+	// The same relation may be declared from both ends, so it is listed once.
+	views := []RelationView{}
+	seen := make(map[RelationView]bool)
+	for _, relation := range stored {
+		view := RelationView{Type: relation.Type, Target: relation.TargetRef}
+		if (relationType != "" && view.Type != relationType) || seen[view] {
+			continue
+		}
+		seen[view] = true
+		views = append(views, view)
+	}
+	sort.Slice(views, func(i, j int) bool {
+		if views[i].Type != views[j].Type {
+			return views[i].Type < views[j].Type
+		}
+		return views[i].Target < views[j].Target
+	})
+	return views, nil
 }
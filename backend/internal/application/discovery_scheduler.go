@@ -0,0 +1,170 @@
+package application
+
+import (
+	"context"
+	"dev-compass/internal/infrastructure/config"
+	"errors"
+	"log"
+	"math/rand/v2"
+	"sync"
+	"time"
+)
+
+// ErrDiscoveryInProgress is returned when a discovery run is requested while another one is still running.
+var ErrDiscoveryInProgress = errors.New("a discovery run is already in progress")
+
+// DiscoveryStatus describes the state of the discovery scheduler.
+type DiscoveryStatus struct {
+	InProgress     bool             `json:"inProgress"`
+	Interval       string           `json:"interval"`
+	Jitter         string           `json:"jitter"`
+	LastRunStarted *time.Time       `json:"lastRunStarted,omitempty"`
+	LastRunEnded   *time.Time       `json:"lastRunEnded,omitempty"`
+	LastRunError   string           `json:"lastRunError,omitempty"`
+	LastReport     *DiscoveryReport `json:"lastReport,omitempty"`
+	NextRun        *time.Time       `json:"nextRun,omitempty"`
+}
+
+// DiscoveryScheduler runs discovery periodically and on demand, never more than one run at a time.
+type DiscoveryScheduler struct {
+	discovery *DiscoveryService
+	interval  time.Duration
+	jitter    time.Duration
+
+	mu      sync.Mutex
+	ctx     context.Context
+	running bool
+	status  DiscoveryStatus
+}
+
+// NewDiscoveryScheduler creates a new DiscoveryScheduler. An interval of zero or less disables periodic runs.
+func NewDiscoveryScheduler(discovery *DiscoveryService, cfg *config.Discovery) *DiscoveryScheduler {
+	return &DiscoveryScheduler{
+		discovery: discovery,
+		interval:  cfg.Interval,
+		jitter:    cfg.Jitter,
+		ctx:       context.Background(),
+		status: DiscoveryStatus{
+			Interval: cfg.Interval.String(),
+			Jitter:   cfg.Jitter.String(),
+		},
+	}
+}
+
+// Start launches the scheduling loop in the background. When runNow is true the
+// first discovery starts immediately instead of after the first interval.
+func (s *DiscoveryScheduler) Start(ctx context.Context, runNow bool) {
+	s.mu.Lock()
+	s.ctx = ctx
+	s.mu.Unlock()
+
+	if s.interval <= 0 {
+		log.Println("INFO: Periodic discovery is disabled.")
+		if runNow {
+			if err := s.TriggerNow(); err != nil {
+				log.Printf("WARN: Could not start discovery: %v", err)
+			}
+		}
+		return
+	}
+
+	go func() {
+		delay := s.nextDelay()
+		if runNow {
+			delay = 0
+		}
+		for {
+			s.setNextRun(time.Now().Add(delay))
+			timer := time.NewTimer(delay)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				s.setNextRun(time.Time{})
+				return
+			case <-timer.C:
+			}
+			s.setNextRun(time.Time{})
+
+			if s.begin() {
+				s.execute(ctx)
+			} else {
+				log.Println("INFO: Skipping scheduled discovery, a run is already in progress.")
+			}
+			delay = s.nextDelay()
+		}
+	}()
+}
+
+// TriggerNow starts a discovery run in the background, unless one is already running.
+func (s *DiscoveryScheduler) TriggerNow() error {
+	if !s.begin() {
+		return ErrDiscoveryInProgress
+	}
+	s.mu.Lock()
+	ctx := s.ctx
+	s.mu.Unlock()
+
+	go s.execute(ctx)
+	return nil
+}
+
+// Status returns a snapshot of the scheduler state.
+func (s *DiscoveryScheduler) Status() DiscoveryStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.status
+}
+
+// begin claims the single run slot, reporting false if a run is already in progress.
+func (s *DiscoveryScheduler) begin() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.running {
+		return false
+	}
+	s.running = true
+	started := time.Now()
+	s.status.InProgress = true
+	s.status.LastRunStarted = &started
+	return true
+}
+
+// execute runs discovery and releases the run slot claimed by begin.
+func (s *DiscoveryScheduler) execute(ctx context.Context) {
+	log.Println("INFO: Starting discovery run...")
+	report, err := s.discovery.RunDiscovery(ctx)
+	if err != nil {
+		log.Printf("ERROR: Discovery run failed: %v", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ended := time.Now()
+	s.running = false
+	s.status.InProgress = false
+	s.status.LastRunEnded = &ended
+	s.status.LastReport = report
+	s.status.LastRunError = ""
+	if err != nil {
+		s.status.LastRunError = err.Error()
+	}
+}
+
+// nextDelay returns the configured interval plus a random jitter, so that
+// several replicas don't hit GitLab at the same moment.
+func (s *DiscoveryScheduler) nextDelay() time.Duration {
+	if s.jitter <= 0 {
+		return s.interval
+	}
+	return s.interval + rand.N(s.jitter)
+}
+
+func (s *DiscoveryScheduler) setNextRun(next time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if next.IsZero() {
+		s.status.NextRun = nil
+		return
+	}
+	s.status.NextRun = &next
+}
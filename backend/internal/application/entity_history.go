@@ -0,0 +1,139 @@
+package application
+
+import (
+	"dev-compass/internal/domain/entities"
+	"dev-compass/internal/domain/ports"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// Operations of a FieldChange.
+const (
+	fieldAdded   = "added"
+	fieldRemoved = "removed"
+	fieldChanged = "changed"
+)
+
+// FieldChange is a change to a single field between two revisions of an entity.
+type FieldChange struct {
+	Path string      `json:"path"` // JSON pointer to the field, e.g. "/spec/owner"
+	Op   string      `json:"op"`   // added, removed or changed
+	From interface{} `json:"from,omitempty"`
+	To   interface{} `json:"to,omitempty"`
+}
+
+// RevisionDiff lists the field-level changes from one revision of an entity to another.
+type RevisionDiff struct {
+	From    int64         `json:"from"`
+	To      int64         `json:"to"`
+	Changes []FieldChange `json:"changes"`
+}
+
+// GetHistory returns the revisions of an entity, newest first. It returns
+// ports.ErrNotFound when the entity has none, which is the case of entities
+// that never existed.
+func (s *CatalogService) GetHistory(kind, namespace, name string) ([]entities.EntityRevision, error) {
+	revisions, err := s.repo.FindRevisions(entities.EntityRef(kind, namespace, name))
+	if err != nil {
+		return nil, err
+	}
+	if len(revisions) == 0 {
+		return nil, ports.ErrNotFound
+	}
+	return revisions, nil
+}
+
+// GetRevision returns a single revision of an entity, with its snapshot.
+func (s *CatalogService) GetRevision(kind, namespace, name string, revision int64) (*entities.EntityRevision, error) {
+	return s.repo.FindRevision(entities.EntityRef(kind, namespace, name), revision)
+}
+
+// DiffRevisions compares two revisions of an entity. A zero to is the latest
+// revision and a zero from the one before to.
+func (s *CatalogService) DiffRevisions(kind, namespace, name string, from, to int64) (*RevisionDiff, error) {
+	ref := entities.EntityRef(kind, namespace, name)
+	if to == 0 {
+		revisions, err := s.repo.FindRevisions(ref)
+		if err != nil {
+			return nil, err
+		}
+		if len(revisions) == 0 {
+			return nil, ports.ErrNotFound
+		}
+		to = revisions[0].Revision
+	}
+	if from == 0 {
+		from = max(to-1, 1)
+	}
+
+	older, err := s.repo.FindRevision(ref, from)
+	if err != nil {
+		return nil, err
+	}
+	newer, err := s.repo.FindRevision(ref, to)
+	if err != nil {
+		return nil, err
+	}
+
+	var before, after interface{}
+	if err := json.Unmarshal(older.Entity, &before); err != nil {
+		return nil, fmt.Errorf("could not read revision %d: %w", from, err)
+	}
+	if err := json.Unmarshal(newer.Entity, &after); err != nil {
+		return nil, fmt.Errorf("could not read revision %d: %w", to, err)
+	}
+	return &RevisionDiff{From: from, To: to, Changes: diffValues("", before, after, []FieldChange{})}, nil
+}
+
+// diffValues appends the changes from before to after to changes. Objects are
+// compared key by key and arrays of the same length item by item; an array that
+// grew or shrank is reported as changed as a whole.
+func diffValues(pointer string, before, after interface{}, changes []FieldChange) []FieldChange {
+	if reflect.DeepEqual(before, after) {
+		return changes
+	}
+
+	switch b := before.(type) {
+	case map[string]interface{}:
+		a, ok := after.(map[string]interface{})
+		if !ok {
+			break
+		}
+		keys := make([]string, 0, len(a)+len(b))
+		for key := range b {
+			keys = append(keys, key)
+		}
+		for key := range a {
+			if _, found := b[key]; !found {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			path := pointer + "/" + escapePointer(key)
+			oldValue, inBefore := b[key]
+			newValue, inAfter := a[key]
+			switch {
+			case !inBefore:
+				changes = append(changes, FieldChange{Path: path, Op: fieldAdded, To: newValue})
+			case !inAfter:
+				changes = append(changes, FieldChange{Path: path, Op: fieldRemoved, From: oldValue})
+			default:
+				changes = diffValues(path, oldValue, newValue, changes)
+			}
+		}
+		return changes
+	case []interface{}:
+		a, ok := after.([]interface{})
+		if !ok || len(a) != len(b) {
+			break
+		}
+		for i := range b {
+			changes = diffValues(fmt.Sprintf("%s/%d", pointer, i), b[i], a[i], changes)
+		}
+		return changes
+	}
+	return append(changes, FieldChange{Path: pointer, Op: fieldChanged, From: before, To: after})
+}
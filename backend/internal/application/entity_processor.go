@@ -0,0 +1,426 @@
+package application
+
+import (
+	"context"
+	"dev-compass/internal/domain/entities"
+	"encoding/json"
+	"fmt"
+	"github.com/mitchellh/mapstructure"
+	"gopkg.in/yaml.v3"
+	"gorm.io/datatypes"
+	"io"
+	"log"
+	"strings"
+)
+
+const (
+	// sourceLocationAnnotation points to the catalog file an entity was read from.
+	sourceLocationAnnotation = "devcompass.io/source-location"
+	// sourcePathAnnotation is the path of that catalog file within its repository.
+	sourcePathAnnotation = "devcompass.io/source-path"
+)
+
+// --- Intermediate structs for safe YAML parsing ---
+type yamlEntity struct {
+	APIVersion string                 `yaml:"apiVersion"`
+	Kind       string                 `yaml:"kind"`
+	Metadata   yamlMetadata           `yaml:"metadata"`
+	Spec       map[string]interface{} `yaml:"spec"` // Generic map to handle different kinds
+
+	location string // Catalog file the document was read from
+	raw      any    // The document as written, validated against the schema of its kind
+}
+type yamlMetadata struct {
+	Name        string            `yaml:"name"`
+	Namespace   string            `yaml:"namespace"`
+	Description string            `yaml:"description"`
+	Tags        []string          `yaml:"tags"`
+	Labels      map[string]string `yaml:"labels"`
+	Annotations map[string]string `yaml:"annotations"`
+	Links       []entities.Link   `yaml:"links"`
+}
+
+// decodeCatalogDocuments parses every YAML document of a catalog file, skipping empty ones.
+func decodeCatalogDocuments(r io.Reader) ([]yamlEntity, error) {
+	decoder := yaml.NewDecoder(r)
+
+	var documents []yamlEntity
+	for {
+		var node yaml.Node
+		if err := decoder.Decode(&node); err != nil {
+			if err == io.EOF {
+				break // End of file
+			}
+			return nil, err
+		}
+
+		// The document is decoded twice: into the known fields, and as is so that
+		// unknown or misspelled fields can be reported by schema validation.
+		var tempEntity yamlEntity
+		if err := node.Decode(&tempEntity); err != nil {
+			return nil, err
+		}
+		if err := node.Decode(&tempEntity.raw); err != nil {
+			return nil, err
+		}
+
+		// Skip empty documents found in the YAML file
+		if tempEntity.Kind == "" || tempEntity.Metadata.Name == "" {
+			continue
+		}
+		documents = append(documents, tempEntity)
+	}
+	return documents, nil
+}
+
+// addTag appends a tag to the entity unless it is already present. When first is
+// true the tag is put in front of the existing ones.
+func (e *yamlEntity) addTag(tag string, first bool) {
+	for _, t := range e.Metadata.Tags {
+		if t == tag {
+			return
+		}
+	}
+	if first {
+		e.Metadata.Tags = append([]string{tag}, e.Metadata.Tags...)
+	} else {
+		e.Metadata.Tags = append(e.Metadata.Tags, tag)
+	}
+}
+
+// annotateSource records on an entity the exact catalog file it was read from.
+// location is a "url:" or "file:" reference to the file; path, when known, is the
+// file path relative to the repository root.
+func annotateSource(entity *entities.Entity, location, path string) {
+	entity.Metadata.Annotations[sourceLocationAnnotation] = location
+	if path != "" {
+		entity.Metadata.Annotations[sourcePathAnnotation] = path
+	}
+}
+
+// componentEnricher adds provider-specific data, such as deployments or CI status, to a ComponentSpec.
+// Data that cannot be gathered is reported to status rather than failing the entity.
+type componentEnricher func(ctx context.Context, spec *entities.ComponentSpec, status *statusCollector)
+
+// maxRepositoryTags is how many of the newest tags enrichers keep in spec.repository.tags.
+const maxRepositoryTags = 10
+
+// Status item types reported while processing entities.
+const (
+	unknownKindStatus            = "devcompass.io/unknown-kind"
+	readmeMissingStatus          = "devcompass.io/readme-missing"
+	tagsUnavailableStatus        = "devcompass.io/tags-unavailable"
+	pipelineUnavailableStatus    = "devcompass.io/pipeline-unavailable"
+	deploymentsUnavailableStatus = "devcompass.io/deployments-unavailable"
+	repositoryFileStatus         = "devcompass.io/repository-file-invalid"
+)
+
+// statusCollector gathers the problems found while processing a single entity,
+// so owners can see what could not be determined about it and why.
+type statusCollector struct {
+	items []entities.StatusItem
+}
+
+// add records a problem. level is info, warning or error.
+func (c *statusCollector) add(level, itemType, format string, args ...interface{}) {
+	c.items = append(c.items, entities.StatusItem{Level: level, Type: itemType, Message: fmt.Sprintf(format, args...)})
+}
+
+// processEntity takes a parsed YAML entity and an optional enricher and returns a final, enriched Entity object.
+// read is used to resolve the placeholders of the spec, relative to the catalog file it was read from.
+// The document is validated against the schema of its kind; violations are recorded in the entity status.
+// A document with unresolved placeholders is not validated, since they would be reported again as violations.
+func processEntity(ctx context.Context, tempEntity *yamlEntity, enrich componentEnricher, read catalogReader) (*entities.Entity, error) {
+	status := &statusCollector{}
+	unresolved := resolveSpecPlaceholders(ctx, tempEntity, read)
+	for _, problem := range unresolved {
+		status.add("error", unresolvedPlaceholderStatus, "%s", problem)
+	}
+	if raw, ok := tempEntity.raw.(map[string]interface{}); ok && tempEntity.Spec != nil {
+		// The resolved spec is validated, not the placeholders.
+		raw["spec"] = tempEntity.Spec
+	}
+
+	var problems []entities.StatusItem
+	if len(unresolved) == 0 {
+		var err error
+		if problems, err = validateDocument(tempEntity); err != nil {
+			return nil, err
+		}
+	}
+	if len(problems) > 0 {
+		log.Printf("WARN: %s %s does not match its schema: %d problems found.", tempEntity.Kind, tempEntity.Metadata.Name, len(problems))
+	}
+
+	finalEntity, err := buildEntity(ctx, tempEntity, enrich, status)
+	if err != nil {
+		if len(problems) > 0 {
+			// The violations point at the exact fields, which the decoding error usually doesn't.
+			var messages []string
+			for _, problem := range problems {
+				messages = append(messages, problem.Message)
+			}
+			return nil, fmt.Errorf("%w (schema violations: %s)", err, strings.Join(messages, "; "))
+		}
+		return nil, err
+	}
+	if items := append(problems, status.items...); len(items) > 0 {
+		finalEntity.Status = &entities.EntityStatus{Items: items}
+	}
+	return finalEntity, nil
+}
+
+// buildEntity converts a parsed YAML entity into its final Entity object, decoding the spec of its kind.
+// Problems that don't prevent building the entity are reported to status.
+func buildEntity(ctx context.Context, tempEntity *yamlEntity, enrich componentEnricher, status *statusCollector) (*entities.Entity, error) {
+	// --- Start with base entity data ---
+	tagsJSON, err := json.Marshal(tempEntity.Metadata.Tags)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal tags for %s: %w", tempEntity.Metadata.Name, err)
+	}
+	labelsMap := make(datatypes.JSONMap)
+	if tempEntity.Metadata.Labels != nil {
+		for k, v := range tempEntity.Metadata.Labels {
+			labelsMap[k] = v
+		}
+	}
+	annotationsMap := make(datatypes.JSONMap)
+	if tempEntity.Metadata.Annotations != nil {
+		for k, v := range tempEntity.Metadata.Annotations {
+			annotationsMap[k] = v
+		}
+	}
+
+	namespace := tempEntity.Metadata.Namespace
+	if namespace == "" {
+		namespace = entities.DefaultNamespace
+	}
+
+	finalEntity := &entities.Entity{
+		APIVersion: tempEntity.APIVersion,
+		Kind:       tempEntity.Kind,
+		Metadata: entities.Metadata{
+			Name:        tempEntity.Metadata.Name,
+			Namespace:   namespace,
+			Description: tempEntity.Metadata.Description,
+			Tags:        datatypes.JSON(tagsJSON),
+			Labels:      labelsMap,
+			Annotations: annotationsMap,
+			Links:       tempEntity.Metadata.Links,
+		},
+	}
+	finalEntity.Ref = finalEntity.CanonicalRef()
+	// Documentation can be attached to any kind; components also show its directory in their spec.
+	docsDir := techDocsRef(tempEntity.Metadata.Annotations, status)
+
+	// --- Process Spec based on Kind ---
+	switch tempEntity.Kind {
+	case "Component":
+		var compSpec entities.ComponentSpec
+
+		// Manually handle fields that are JSON in the DB model but structured in YAML/JSON input
+		if relationsData, ok := tempEntity.Spec["relations"]; ok {
+			if compSpec.Relations, err = json.Marshal(relationsData); err != nil {
+				return nil, fmt.Errorf("failed to marshal relations for %s: %w", tempEntity.Metadata.Name, err)
+			}
+			delete(tempEntity.Spec, "relations") // Remove from map before decoding the rest
+		}
+		if repoData, ok := tempEntity.Spec["repository"].(map[string]interface{}); ok {
+			if tagsData, ok := repoData["tags"]; ok {
+				if compSpec.Repository.Tags, err = json.Marshal(tagsData); err != nil {
+					return nil, fmt.Errorf("failed to marshal repository tags for %s: %w", tempEntity.Metadata.Name, err)
+				}
+				delete(repoData, "tags")
+			}
+		}
+
+		if err := mapstructure.Decode(tempEntity.Spec, &compSpec); err != nil {
+			return nil, fmt.Errorf("failed to decode Component spec for %s: %w", tempEntity.Metadata.Name, err)
+		}
+
+		// --- Logic for relations (including shorthand) ---
+		shorthandRelations := processShorthandRelations(tempEntity.Spec)
+		if len(shorthandRelations) > 0 {
+			// Unmarshal existing relations if they exist
+			var existingRelations []entities.Relation
+			if len(compSpec.Relations) > 0 {
+				if err := json.Unmarshal(compSpec.Relations, &existingRelations); err != nil {
+					log.Printf("WARN: could not unmarshal existing relations for %s: %v", tempEntity.Metadata.Name, err)
+				}
+			}
+			if compSpec.Relations, err = json.Marshal(append(existingRelations, shorthandRelations...)); err != nil {
+				return nil, fmt.Errorf("failed to marshal relations for %s: %w", tempEntity.Metadata.Name, err)
+			}
+		}
+
+		if docsDir != "" {
+			compSpec.TechDocs.Dir = docsDir
+		}
+
+		// --- Enrich ComponentSpec with data from the provider (if available) ---
+		if enrich != nil {
+			enrich(ctx, &compSpec, status)
+		}
+
+		// Marshal the final, enriched spec back to JSON for storage
+		specJSON, err := json.Marshal(compSpec)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal final Component spec for %s: %w", tempEntity.Metadata.Name, err)
+		}
+		finalEntity.Spec = specJSON
+
+	case "Resource":
+		var resSpec entities.ResourceSpec
+		if err := mapstructure.Decode(tempEntity.Spec, &resSpec); err != nil {
+			return nil, fmt.Errorf("failed to decode Resource spec for %s: %w", tempEntity.Metadata.Name, err)
+		}
+		// No enrichment for resources yet
+		specJSON, err := json.Marshal(resSpec)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal final Resource spec for %s: %w", tempEntity.Metadata.Name, err)
+		}
+		finalEntity.Spec = specJSON
+
+	case "API":
+		definition, err := apiDefinition(tempEntity.Spec["definition"])
+		if err != nil {
+			return nil, fmt.Errorf("failed to read API definition for %s: %w", tempEntity.Metadata.Name, err)
+		}
+		delete(tempEntity.Spec, "definition") // Remove from map before decoding the rest
+
+		apiSpec := entities.APISpec{Definition: definition}
+		if err := mapstructure.Decode(tempEntity.Spec, &apiSpec); err != nil {
+			return nil, fmt.Errorf("failed to decode API spec for %s: %w", tempEntity.Metadata.Name, err)
+		}
+		specJSON, err := json.Marshal(apiSpec)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal final API spec for %s: %w", tempEntity.Metadata.Name, err)
+		}
+		finalEntity.Spec = specJSON
+
+	case "System":
+		var sysSpec entities.SystemSpec
+		if err := mapstructure.Decode(tempEntity.Spec, &sysSpec); err != nil {
+			return nil, fmt.Errorf("failed to decode System spec for %s: %w", tempEntity.Metadata.Name, err)
+		}
+		specJSON, err := json.Marshal(sysSpec)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal final System spec for %s: %w", tempEntity.Metadata.Name, err)
+		}
+		finalEntity.Spec = specJSON
+
+	case "Domain":
+		var domainSpec entities.DomainSpec
+		if err := mapstructure.Decode(tempEntity.Spec, &domainSpec); err != nil {
+			return nil, fmt.Errorf("failed to decode Domain spec for %s: %w", tempEntity.Metadata.Name, err)
+		}
+		specJSON, err := json.Marshal(domainSpec)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal final Domain spec for %s: %w", tempEntity.Metadata.Name, err)
+		}
+		finalEntity.Spec = specJSON
+
+	case "Group":
+		var groupSpec entities.GroupSpec
+		if err := mapstructure.Decode(tempEntity.Spec, &groupSpec); err != nil {
+			return nil, fmt.Errorf("failed to decode Group spec for %s: %w", tempEntity.Metadata.Name, err)
+		}
+		if groupSpec.Children == nil {
+			groupSpec.Children = []string{}
+		}
+		specJSON, err := json.Marshal(groupSpec)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal final Group spec for %s: %w", tempEntity.Metadata.Name, err)
+		}
+		finalEntity.Spec = specJSON
+
+	case "User":
+		var userSpec entities.UserSpec
+		if err := mapstructure.Decode(tempEntity.Spec, &userSpec); err != nil {
+			return nil, fmt.Errorf("failed to decode User spec for %s: %w", tempEntity.Metadata.Name, err)
+		}
+		if userSpec.MemberOf == nil {
+			userSpec.MemberOf = []string{}
+		}
+		specJSON, err := json.Marshal(userSpec)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal final User spec for %s: %w", tempEntity.Metadata.Name, err)
+		}
+		finalEntity.Spec = specJSON
+
+	case "Location":
+		var locSpec entities.LocationSpec
+		if err := mapstructure.Decode(tempEntity.Spec, &locSpec); err != nil {
+			return nil, fmt.Errorf("failed to decode Location spec for %s: %w", tempEntity.Metadata.Name, err)
+		}
+		// The targets themselves are followed when the catalog file is read
+		specJSON, err := json.Marshal(locSpec)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal final Location spec for %s: %w", tempEntity.Metadata.Name, err)
+		}
+		finalEntity.Spec = specJSON
+
+	default:
+		log.Printf("WARN: Unknown entity kind '%s' for %s. Skipping spec processing.", tempEntity.Kind, tempEntity.Metadata.Name)
+		status.add("warning", unknownKindStatus, "kind %q is not supported, its spec was not read", tempEntity.Kind)
+		finalEntity.Spec = datatypes.JSON("{}")
+	}
+
+	return finalEntity, nil
+}
+
+// apiDefinition returns the definition of an API entity as a string. A definition
+// pulled in with $json or $yaml is structured, and is stored as JSON.
+func apiDefinition(definition interface{}) (string, error) {
+	switch definition := definition.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return definition, nil
+	case map[string]interface{}:
+		data, err := json.Marshal(definition)
+		if err != nil {
+			return "", err
+		}
+		return string(data), nil
+	default:
+		return "", fmt.Errorf("definition must be a string or an object")
+	}
+}
+
+// shorthandRelations are the spec fields that declare relations, with the type of
+// the relation and the kind of targets given without one. They are a slice rather
+// than a map so relations come out in the same order on every run, keeping the
+// content hash of unchanged entities stable.
+var shorthandRelations = []struct {
+	key, relationType, defaultKind string
+}{
+	{"dependsOn", "dependsOn", "Component"},
+	{"dependencyOf", "dependencyOf", "Component"},
+	{"providesApis", "providesApi", "API"},
+	{"consumesApis", "consumesApi", "API"},
+	{"partOf", "partOf", "System"},
+	{"hasPart", "hasPart", "Component"},
+}
+
+// processShorthandRelations parses shorthand relation fields from a generic spec map.
+func processShorthandRelations(spec map[string]interface{}) []entities.Relation {
+	var newRelations []entities.Relation
+	for _, shorthand := range shorthandRelations {
+		if refs, ok := spec[shorthand.key].([]interface{}); ok {
+			for _, ref := range refs {
+				if refStr, ok := ref.(string); ok {
+					target := entities.ParseEntityRef(refStr)
+					if target.Kind == "" {
+						target.Kind = shorthand.defaultKind
+					}
+					newRelations = append(newRelations, entities.Relation{
+						Type:   shorthand.relationType,
+						Target: target,
+					})
+				}
+			}
+		}
+	}
+	return newRelations
+}
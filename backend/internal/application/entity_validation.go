@@ -0,0 +1,64 @@
+package application
+
+import (
+	"bytes"
+	"dev-compass/internal/domain/entities"
+	"dev-compass/internal/domain/schemas"
+	"encoding/json"
+	"fmt"
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"strings"
+)
+
+// schemaValidationStatus is the status item type used for documents that don't match their schema.
+const schemaValidationStatus = "devcompass.io/schema-validation"
+
+// validationReject is the CATALOG_VALIDATION_MODE that leaves invalid entities out of the catalog.
+// In any other mode they are stored, flagged with their schema violations.
+const validationReject = "reject"
+
+// validateDocument checks a catalog document, as it was written, against the
+// schema of its apiVersion and kind. It returns a status item per violation.
+func validateDocument(tempEntity *yamlEntity) ([]entities.StatusItem, error) {
+	if tempEntity.raw == nil {
+		return nil, nil
+	}
+
+	// The schema validator only understands JSON values, so the YAML document is converted first.
+	data, err := json.Marshal(tempEntity.raw)
+	if err != nil {
+		return []entities.StatusItem{schemaViolation(fmt.Sprintf("document cannot be represented as JSON: %v", err))}, nil
+	}
+	document, err := jsonschema.UnmarshalJSON(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	violations, err := schemas.Validate(tempEntity.APIVersion, tempEntity.Kind, document)
+	if err != nil {
+		return nil, fmt.Errorf("failed to validate %s: %w", tempEntity.Metadata.Name, err)
+	}
+	var items []entities.StatusItem
+	for _, violation := range violations {
+		items = append(items, schemaViolation(violation.String()))
+	}
+	return items, nil
+}
+
+func schemaViolation(message string) entities.StatusItem {
+	return entities.StatusItem{Level: "error", Type: schemaValidationStatus, Message: message}
+}
+
+// schemaViolations returns the schema violations recorded on an entity, joined in a single message.
+func schemaViolations(entity *entities.Entity) string {
+	if entity.Status == nil {
+		return ""
+	}
+	var messages []string
+	for _, item := range entity.Status.Items {
+		if item.Type == schemaValidationStatus {
+			messages = append(messages, item.Message)
+		}
+	}
+	return strings.Join(messages, "; ")
+}
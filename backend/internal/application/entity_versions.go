@@ -0,0 +1,70 @@
+package application
+
+import (
+	"crypto/sha256"
+	"dev-compass/internal/domain/entities"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/google/uuid"
+	"reflect"
+	"strings"
+)
+
+// stampEntity sets the UID, generation and etag of an entity about to be saved.
+// previous is the stored version of the entity, or nil when it is new: its UID
+// is kept and the generation only moves on when the spec changed.
+func stampEntity(entity *entities.Entity, previous *entities.Entity) {
+	entity.Metadata.UID = uuid.NewString()
+	entity.Metadata.Generation = 1
+	if previous != nil {
+		if previous.Metadata.UID != "" {
+			entity.Metadata.UID = previous.Metadata.UID
+		}
+		entity.Metadata.Generation = max(previous.Metadata.Generation, 1)
+		if !sameJSON(entity.Spec, previous.Spec) {
+			entity.Metadata.Generation++
+		}
+	}
+	entity.Metadata.ETag = entityETag(entity)
+}
+
+// entityETag returns a digest of everything stored for an entity, so it changes on every write.
+func entityETag(entity *entities.Entity) string {
+	stored := *entity
+	stored.Metadata.ETag = ""
+	data, err := json.Marshal(stored)
+	if err != nil {
+		// Not expected for a stored entity; a random etag still changes on every write.
+		data = []byte(uuid.NewString())
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:8])
+}
+
+// etagMatches reports whether an If-Match header value matches the etag of an entity.
+// The header may list several quoted etags, or "*" to match any. If-Match uses
+// the strong comparison of RFC 7232, so weak etags never match.
+func etagMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" {
+			return true
+		}
+		if strings.HasPrefix(candidate, "W/") {
+			continue
+		}
+		if strings.Trim(candidate, `"`) == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// sameJSON reports whether two JSON documents hold the same values, ignoring formatting and key order.
+func sameJSON(a, b []byte) bool {
+	var left, right interface{}
+	if json.Unmarshal(a, &left) != nil || json.Unmarshal(b, &right) != nil {
+		return string(a) == string(b)
+	}
+	return reflect.DeepEqual(left, right)
+}
@@ -0,0 +1,26 @@
+package application
+
+import "testing"
+
+func TestETagMatches(t *testing.T) {
+	tests := []struct {
+		header string
+		want   bool
+	}{
+		{`"abc"`, true},
+		{`abc`, true},
+		{`*`, true},
+		{`"other"`, false},
+		{`"other", "abc"`, true},
+		{`"other","abc"`, true},
+		{`W/"abc"`, false},
+		{`W/"abc", "abc"`, true},
+		{`W/"abc", "other"`, false},
+		{``, false},
+	}
+	for _, tt := range tests {
+		if got := etagMatches(tt.header, "abc"); got != tt.want {
+			t.Errorf("etagMatches(%q, \"abc\") = %v, want %v", tt.header, got, tt.want)
+		}
+	}
+}
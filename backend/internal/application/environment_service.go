@@ -4,9 +4,9 @@ import (
 	"dev-compass/internal/domain/entities"
 	"dev-compass/internal/domain/ports"
 	"encoding/json"
+	"errors"
 	"log"
 	"sort"
-	"strings"
 )
 
 // --- New Response Structures ---
@@ -22,6 +22,7 @@ type DeploymentVersion struct {
 // GroupedComponent holds a component name and all its deployed versions.
 type GroupedComponent struct {
 	ComponentName string              `json:"componentName"`
+	EntityRef     string              `json:"entityRef"`
 	Deployments   []DeploymentVersion `json:"deployments"`
 }
 
@@ -41,6 +42,7 @@ type EnvironmentWithPagination struct {
 // --- Original struct for processing, not for final output ---
 type DeploymentComponent struct {
 	ComponentName string
+	EntityRef     string
 	Version       string
 	Timestamp     string
 	Entidad       string
@@ -70,15 +72,28 @@ func (s *EnvironmentService) GetEnvironments(search string, page int, limit int)
 		{"development", "wg_adquirencia_dev", "Entorno de desarrollo para nuevas funcionalidades."},
 	}
 
-	allEntities, err := s.repo.FindAll("", "") // Fetch all, filter in memory
+	allEntities, err := s.repo.FindAll() // Fetch all, filter in memory
 	if err != nil {
 		return nil, err
 	}
 
+	// Components are found by what they do too, not only by their name.
+	var matching map[string]bool
+	if search != "" {
+		results, err := s.repo.Search(search, ports.EntityQuery{Kinds: []string{"Component"}})
+		if err != nil {
+			return nil, err
+		}
+		matching = make(map[string]bool, len(results))
+		for _, result := range results {
+			matching[result.Entity.Ref] = true
+		}
+	}
+
 	// 1. Filter entities by search term and build a map of all deployments per environment
 	environmentMap := make(map[string][]DeploymentComponent)
 	for _, entity := range allEntities {
-		if search != "" && !strings.Contains(strings.ToLower(entity.Metadata.Name), strings.ToLower(search)) {
+		if matching != nil && !matching[entity.Ref] {
 			continue
 		}
 
@@ -91,6 +106,7 @@ func (s *EnvironmentService) GetEnvironments(search string, page int, limit int)
 			for _, dep := range spec.Deployments {
 				environmentMap[dep.Environment] = append(environmentMap[dep.Environment], DeploymentComponent{
 					ComponentName: entity.Metadata.Name,
+					EntityRef:     entity.Ref,
 					Version:       dep.Version,
 					Timestamp:     dep.Timestamp,
 					Entidad:       dep.Entidad,
@@ -104,10 +120,13 @@ func (s *EnvironmentService) GetEnvironments(search string, page int, limit int)
 	for _, envDef := range envDefinitions {
 		deployments := environmentMap[envDef.LongName]
 
-		// 2. Group deployments by component name for the current environment
+		// 2. Group deployments by component for the current environment. Names can
+		// repeat across namespaces, so components are told apart by their ref.
 		componentGroupMap := make(map[string][]DeploymentVersion)
+		componentNames := make(map[string]string)
 		for _, dep := range deployments {
-			componentGroupMap[dep.ComponentName] = append(componentGroupMap[dep.ComponentName], DeploymentVersion{
+			componentNames[dep.EntityRef] = dep.ComponentName
+			componentGroupMap[dep.EntityRef] = append(componentGroupMap[dep.EntityRef], DeploymentVersion{
 				Version:    dep.Version,
 				Timestamp:  dep.Timestamp,
 				Entidad:    dep.Entidad,
@@ -117,13 +136,16 @@ func (s *EnvironmentService) GetEnvironments(search string, page int, limit int)
 
 		// Convert map to slice to make it sortable
 		groupedComponents := make([]GroupedComponent, 0, len(componentGroupMap))
-		for name, deps := range componentGroupMap {
-			groupedComponents = append(groupedComponents, GroupedComponent{ComponentName: name, Deployments: deps})
+		for ref, deps := range componentGroupMap {
+			groupedComponents = append(groupedComponents, GroupedComponent{ComponentName: componentNames[ref], EntityRef: ref, Deployments: deps})
 		}
 
 		// Sort grouped components by name for consistent ordering
 		sort.Slice(groupedComponents, func(i, j int) bool {
-			return groupedComponents[i].ComponentName < groupedComponents[j].ComponentName
+			if groupedComponents[i].ComponentName != groupedComponents[j].ComponentName {
+				return groupedComponents[i].ComponentName < groupedComponents[j].ComponentName
+			}
+			return groupedComponents[i].EntityRef < groupedComponents[j].EntityRef
 		})
 
 		// 3. Paginate the list of grouped components
@@ -164,40 +186,36 @@ type ComponentDeployment struct {
 	Entidad     string `json:"entidad,omitempty"`
 }
 
-// GetEnvironmentsByComponent finds a single component by name and returns its deployment locations.
+// GetEnvironmentsByComponent returns the deployment locations of a component in the default namespace.
 func (s *EnvironmentService) GetEnvironmentsByComponent(componentName string) ([]ComponentDeployment, error) {
-	// Since there is no FindByName, we fetch all and filter in memory.
-	allEntities, err := s.repo.FindAll("", "")
+	return s.GetEnvironmentsByEntity("Component", entities.DefaultNamespace, componentName)
+}
+
+// GetEnvironmentsByEntity finds a single entity by kind, namespace and name and returns its deployment locations.
+func (s *EnvironmentService) GetEnvironmentsByEntity(kind, namespace, name string) ([]ComponentDeployment, error) {
+	entity, err := s.repo.FindByRef(entities.EntityRef(kind, namespace, name))
 	if err != nil {
+		if errors.Is(err, ports.ErrNotFound) {
+			// Entity not found
+			return []ComponentDeployment{}, nil
+		}
 		return nil, err
 	}
 
-	for _, entity := range allEntities {
-		if entity.Metadata.Name == componentName {
-			var spec entities.ComponentSpec
-			if err := json.Unmarshal(entity.Spec, &spec); err != nil {
-				// Log the error but continue, as other entities might be valid
-				log.Printf("ERROR: could not unmarshal spec for entity %s: %v", entity.Metadata.Name, err)
-				continue
-			}
+	var spec entities.ComponentSpec
+	if err := json.Unmarshal(entity.Spec, &spec); err != nil {
+		log.Printf("ERROR: could not unmarshal spec for entity %s: %v", entity.Ref, err)
+		return []ComponentDeployment{}, nil
+	}
 
-			if spec.Deployments != nil {
-				deployments := make([]ComponentDeployment, len(spec.Deployments))
-				for i, dep := range spec.Deployments {
-					deployments[i] = ComponentDeployment{
-						Environment: dep.Environment,
-						Version:     dep.Version,
-						Timestamp:   dep.Timestamp,
-						Entidad:     dep.Entidad,
-					}
-				}
-				return deployments, nil
-			}
-			// Found component but it has no deployment info
-			return []ComponentDeployment{}, nil
+	deployments := make([]ComponentDeployment, len(spec.Deployments))
+	for i, dep := range spec.Deployments {
+		deployments[i] = ComponentDeployment{
+			Environment: dep.Environment,
+			Version:     dep.Version,
+			Timestamp:   dep.Timestamp,
+			Entidad:     dep.Entidad,
 		}
 	}
-
-	// Component not found
-	return []ComponentDeployment{}, nil
+	return deployments, nil
 }
@@ -0,0 +1,101 @@
+package application
+
+import (
+	"context"
+	"dev-compass/internal/domain/entities"
+	"dev-compass/internal/domain/ports"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+// FileProvider reads entities from catalog YAML files on the local filesystem.
+type FileProvider struct {
+	paths    []string
+	external map[string]bool
+	urls     *urlReader
+}
+
+// NewFileProvider creates a new FileProvider. Entities read from any of the
+// externalPaths are tagged as 'external'.
+func NewFileProvider(paths, externalPaths []string, urls *urlReader) *FileProvider {
+	external := make(map[string]bool, len(externalPaths))
+	for _, path := range externalPaths {
+		external[path] = true
+	}
+	return &FileProvider{paths: paths, external: external, urls: urls}
+}
+
+// Name returns the provider name.
+func (p *FileProvider) Name() string {
+	return "file"
+}
+
+// Provide reads every configured file. A file that no longer exists is reported
+// as an empty source, so its entities are removed.
+func (p *FileProvider) Provide(ctx context.Context, emit func(ports.SourceResult)) error {
+	for _, path := range p.paths {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		source := p.Name() + ":" + path
+		discovered, err := p.ingestLocalFile(ctx, path)
+		if err != nil && os.IsNotExist(err) {
+			log.Printf("WARN: Catalog file %s does not exist, removing its entities.", path)
+			emit(ports.SourceResult{Source: source})
+			continue
+		}
+		emit(ports.SourceResult{Source: source, Entities: discovered, Err: err})
+	}
+	return nil
+}
+
+// ingestLocalFile processes a single YAML file that may contain multiple entity
+// definitions, along with the files referenced by its Location entities.
+func (p *FileProvider) ingestLocalFile(ctx context.Context, path string) ([]*entities.Entity, error) {
+	log.Printf("INFO: Ingesting local file: %s", path)
+	content, err := readLocalFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	readFile := func(ctx context.Context, target string) ([]byte, error) {
+		return readLocalFile(filepath.FromSlash(target))
+	}
+	read := withRemoteTargets(p.urls, readFile)
+	documents, err := readCatalogTree(ctx, filepath.ToSlash(path), content, read)
+	if err != nil {
+		return nil, err
+	}
+
+	var discovered []*entities.Entity
+	for i := range documents {
+		tempEntity := &documents[i]
+
+		// Add 'resource' tag if the kind is Resource
+		if tempEntity.Kind == "Resource" {
+			tempEntity.addTag("resource", true)
+		}
+
+		// Conditionally add the 'external' tag
+		if p.external[path] {
+			tempEntity.addTag("external", false)
+		}
+
+		// Process the generic entity
+		// For local files there is no provider data to enrich with, so we pass nil.
+		finalEntity, err := processEntity(ctx, tempEntity, nil, read)
+		if err != nil {
+			return nil, fmt.Errorf("failed to process local entity %s: %w", tempEntity.Metadata.Name, err)
+		}
+		if isRemoteTarget(tempEntity.location) {
+			annotateSource(finalEntity, "url:"+tempEntity.location, "")
+		} else {
+			annotateSource(finalEntity, "file:"+tempEntity.location, "")
+		}
+		discovered = append(discovered, finalEntity)
+	}
+	return discovered, nil
+}
@@ -0,0 +1,232 @@
+package application
+
+import (
+	"context"
+	"dev-compass/internal/domain/entities"
+	"dev-compass/internal/domain/ports"
+	"dev-compass/internal/infrastructure/config"
+	"dev-compass/internal/infrastructure/github"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"strings"
+	"time"
+)
+
+// GitHubProvider discovers entities from the catalog files of every repository in a GitHub organization.
+type GitHubProvider struct {
+	cfg    *config.Config
+	urls   *urlReader
+	client *github.Client
+}
+
+// NewGitHubProvider creates a new GitHubProvider.
+func NewGitHubProvider(cfg *config.Config, urls *urlReader) (*GitHubProvider, error) {
+	if cfg.GitHub.Organization == "" {
+		return nil, fmt.Errorf("GITHUB_ORG is not configured")
+	}
+	return &GitHubProvider{
+		cfg:    cfg,
+		urls:   urls,
+		client: github.NewClient(cfg.GitHub.APIURL, cfg.GitHub.Token),
+	}, nil
+}
+
+// Name returns the provider name.
+func (p *GitHubProvider) Name() string {
+	return "github"
+}
+
+// Provide scans every repository of the configured organization.
+func (p *GitHubProvider) Provide(ctx context.Context, emit func(ports.SourceResult)) error {
+	log.Println("INFO: Starting GitHub discovery process...")
+	org := p.cfg.GitHub.Organization
+
+	repos, truncated, err := p.client.ListOrgRepositories(ctx, org, p.cfg.GitHub.MaxListItems)
+	if err != nil {
+		return fmt.Errorf("failed to list repositories of organization %s: %w", org, err)
+	}
+	if truncated {
+		log.Printf("WARN: Organization %s has more than %d repositories, the rest will not be scanned. Raise GITHUB_MAX_LIST_ITEMS to cover them.", org, p.cfg.GitHub.MaxListItems)
+	}
+
+	log.Printf("INFO: Found %d repositories to scan with %d workers.", len(repos), p.cfg.Discovery.Concurrency)
+	forEachConcurrently(ctx, p.cfg.Discovery.Concurrency, repos, func(repo github.Repository) {
+		discovered, err := p.scanRepository(ctx, repo)
+		emit(ports.SourceResult{Source: p.Name() + ":" + repo.FullName, Entities: discovered, Err: err})
+	})
+
+	// Repositories that were never scanned must not be mistaken for deleted ones.
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("GitHub discovery was cancelled: %w", err)
+	}
+	if truncated {
+		return fmt.Errorf("organization %s has more than %d repositories", org, p.cfg.GitHub.MaxListItems)
+	}
+
+	log.Println("INFO: GitHub discovery process finished.")
+	return nil
+}
+
+// scanRepository reads the catalog file of a single repository from its default
+// branch. A repository without a catalog file yields no entities and no error.
+func (p *GitHubProvider) scanRepository(ctx context.Context, repo github.Repository) ([]*entities.Entity, error) {
+	log.Printf("INFO: Scanning repository: %s", repo.FullName)
+	owner, name := splitFullName(repo.FullName)
+
+	readFile := func(ctx context.Context, target string) ([]byte, error) {
+		filename, err := repositoryPath(target)
+		if err != nil {
+			return nil, err
+		}
+		data, err := p.client.GetFile(ctx, owner, name, filename, repo.DefaultBranch)
+		if errors.Is(err, github.ErrNotFound) {
+			return nil, fmt.Errorf("%s: %w", filename, fs.ErrNotExist)
+		}
+		return data, err
+	}
+
+	var root string
+	var content []byte
+	possibleFilenames := []string{"catalog-info.yaml", "catalog-info.yml", "devcompass.yaml", "devcompass.yml"}
+	for _, filename := range possibleFilenames {
+		data, err := readFile(ctx, filename)
+		if err == nil {
+			root, content = filename, data
+			break
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("failed to read %s in %s: %w", filename, repo.FullName, err)
+		}
+	}
+
+	if content == nil {
+		log.Printf("DEBUG: Could not find a catalog file in %s", repo.FullName)
+		return nil, nil
+	}
+
+	read := withRemoteTargets(p.urls, readFile)
+	documents, err := readCatalogTree(ctx, root, content, read)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read catalog of %s: %w", repo.FullName, err)
+	}
+
+	var discovered []*entities.Entity
+	for i := range documents {
+		finalEntity, err := processEntity(ctx, &documents[i], func(ctx context.Context, spec *entities.ComponentSpec, status *statusCollector) {
+			p.enrichComponentSpec(ctx, spec, repo, status)
+			spec.ProjectURL = repo.HTMLURL
+		}, read)
+		if err != nil {
+			return nil, fmt.Errorf("failed to process entity from %s: %w", repo.FullName, err)
+		}
+		if location := documents[i].location; isRemoteTarget(location) {
+			annotateSource(finalEntity, "url:"+location, "")
+		} else {
+			annotateSource(finalEntity, "url:"+repo.HTMLURL+"/blob/"+repo.DefaultBranch+"/"+location, location)
+		}
+		discovered = append(discovered, finalEntity)
+	}
+	return discovered, nil
+}
+
+// enrichComponentSpec populates a ComponentSpec with data fetched from the GitHub API.
+func (p *GitHubProvider) enrichComponentSpec(ctx context.Context, spec *entities.ComponentSpec, repo github.Repository, status *statusCollector) {
+	owner, name := splitFullName(repo.FullName)
+
+	// --- Fetch README ---
+	readme, err := p.client.GetReadme(ctx, owner, name, repo.DefaultBranch)
+	switch {
+	case errors.Is(err, github.ErrNotFound):
+		log.Printf("DEBUG: No README found for %s, continuing without it.", repo.FullName)
+		status.add("info", readmeMissingStatus, "no README found in the repository")
+	case err != nil:
+		log.Printf("WARN: Could not fetch the README of repository %s: %v", repo.FullName, err)
+		status.add("warning", readmeMissingStatus, "could not fetch the README: %v", err)
+	default:
+		spec.ReadmeContent = string(readme)
+	}
+
+	// --- Releases, falling back to plain tags ---
+	var collectedTags []map[string]string
+	releases, err := p.client.ListReleases(ctx, owner, name, maxRepositoryTags)
+	if err != nil {
+		log.Printf("WARN: Could not fetch releases for repository %s: %v", repo.FullName, err)
+		status.add("warning", tagsUnavailableStatus, "could not fetch the repository releases: %v", err)
+	}
+	for _, release := range releases {
+		if release.Draft {
+			continue
+		}
+		timestamp := release.PublishedAt
+		if timestamp.IsZero() {
+			timestamp = release.CreatedAt
+		}
+		collectedTags = append(collectedTags, map[string]string{
+			"name":      release.TagName,
+			"timestamp": timestamp.Format(time.RFC3339),
+		})
+	}
+	if len(collectedTags) == 0 {
+		tags, err := p.client.ListTags(ctx, owner, name, maxRepositoryTags)
+		if err != nil {
+			log.Printf("WARN: Could not fetch tags for repository %s: %v", repo.FullName, err)
+			status.add("warning", tagsUnavailableStatus, "could not fetch the repository tags: %v", err)
+		}
+		for _, tag := range tags {
+			// The tags listing carries no dates; fetching each commit would cost one call per tag.
+			collectedTags = append(collectedTags, map[string]string{"name": tag.Name})
+		}
+	}
+	if len(collectedTags) > 0 {
+		if spec.Repository.Tags, err = json.Marshal(collectedTags); err != nil {
+			status.add("warning", tagsUnavailableStatus, "could not store the repository tags: %v", err)
+		}
+	}
+
+	// --- Latest GitHub Actions run on the default branch ---
+	runs, err := p.client.ListWorkflowRuns(ctx, owner, name, repo.DefaultBranch, 1)
+	if err != nil {
+		log.Printf("WARN: Could not fetch workflow runs for repository %s: %v", repo.FullName, err)
+		status.add("warning", pipelineUnavailableStatus, "could not fetch the latest workflow run: %v", err)
+	} else if len(runs) > 0 {
+		spec.CI.LastRunStatus = workflowRunStatus(runs[0])
+		spec.CI.PipelineURL = runs[0].HTMLURL
+	}
+
+	// --- Enrich with the Dockerfile and CI/CD files ---
+	applyRepositoryFiles(spec, repo.Name, func(filename string) (string, error) {
+		data, err := p.client.GetFile(ctx, owner, name, filename, repo.DefaultBranch)
+		if err != nil && !errors.Is(err, github.ErrNotFound) {
+			// A missing file is expected; any other failure leaves the spec incomplete.
+			log.Printf("WARN: Could not fetch %s of repository %s: %v", filename, repo.FullName, err)
+			status.add("warning", repositoryFileStatus, "could not fetch %s: %v", filename, err)
+		}
+		return string(data), err
+	}, status)
+}
+
+// workflowRunStatus maps a GitHub Actions run to the pipeline statuses used for GitLab.
+func workflowRunStatus(run github.WorkflowRun) string {
+	if run.Status != "completed" {
+		return "running"
+	}
+	switch run.Conclusion {
+	case "success":
+		return "success"
+	case "cancelled":
+		return "canceled"
+	case "skipped", "neutral":
+		return "skipped"
+	default:
+		return "failed"
+	}
+}
+
+// splitFullName splits an "owner/repo" name.
+func splitFullName(fullName string) (string, string) {
+	owner, name, _ := strings.Cut(fullName, "/")
+	return owner, name
+}
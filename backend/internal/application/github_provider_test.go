@@ -0,0 +1,174 @@
+package application
+
+import (
+	"context"
+	"dev-compass/internal/domain/entities"
+	"dev-compass/internal/domain/ports"
+	"dev-compass/internal/infrastructure/config"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+// fakeGitHub serves the GitHub API responses of an organization with three
+// repositories: payments, with a catalog file and releases, billing, with a
+// catalog file and plain tags only, and empty, without a catalog file.
+func fakeGitHub(t *testing.T) *httptest.Server {
+	t.Helper()
+	file := func(content string) string {
+		data, _ := json.Marshal(map[string]string{"content": base64.StdEncoding.EncodeToString([]byte(content)), "encoding": "base64"})
+		return string(data)
+	}
+	repository := func(name string) map[string]string {
+		return map[string]string{"name": name, "full_name": "acme/" + name, "html_url": "https://github.com/acme/" + name, "default_branch": "main"}
+	}
+	catalog := func(name string) string {
+		return file("apiVersion: backstage.io/v1alpha1\nkind: Component\nmetadata:\n  name: " + name + "\nspec:\n  type: service\n  lifecycle: production\n  owner: team-a\n")
+	}
+
+	responses := map[string]string{
+		"/repos/acme/payments/contents/catalog-info.yaml": catalog("payments"),
+		"/repos/acme/payments/readme":                     file("# Payments"),
+		"/repos/acme/payments/contents/Dockerfile":        file("FROM golang:1.24 AS build\nFROM alpine:3.20\n"),
+		"/repos/acme/payments/releases": `[
+			{"tag_name": "v1.3.0-rc", "draft": true, "created_at": "2026-03-01T00:00:00Z"},
+			{"tag_name": "v1.2.0", "created_at": "2026-02-01T00:00:00Z", "published_at": "2026-02-02T10:00:00Z"}
+		]`,
+		"/repos/acme/payments/actions/runs": `{"workflow_runs": [
+			{"id": 7, "status": "completed", "conclusion": "success", "html_url": "https://github.com/acme/payments/actions/runs/7"}
+		]}`,
+		"/repos/acme/billing/contents/catalog-info.yaml": catalog("billing"),
+		"/repos/acme/billing/contents/dockerfile":        file("FROM node:22\n"),
+		"/repos/acme/billing/releases":                   `[]`,
+		"/repos/acme/billing/tags":                       `[{"name": "v0.1.0", "commit": {"sha": "abc"}}]`,
+		"/repos/acme/billing/actions/runs":               `{"workflow_runs": [{"id": 8, "status": "in_progress"}]}`,
+	}
+	failing := map[string]bool{
+		"/repos/acme/payments/contents/deploy-ecs-fargate.yml": true,
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/orgs/acme/repos" {
+			_ = json.NewEncoder(w).Encode([]map[string]string{repository("payments"), repository("billing"), repository("empty")})
+			return
+		}
+		if failing[r.URL.Path] {
+			http.Error(w, `{"message": "Server Error"}`, http.StatusInternalServerError)
+			return
+		}
+		body, ok := responses[r.URL.Path]
+		if !ok {
+			http.Error(w, `{"message": "Not Found"}`, http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestGitHubProviderDiscoversAndEnrichesComponents(t *testing.T) {
+	server := fakeGitHub(t)
+	cfg := &config.Config{
+		GitLab:    &config.GitLab{},
+		GitHub:    &config.GitHub{APIURL: server.URL, Organization: "acme", MaxListItems: 100},
+		Discovery: &config.Discovery{Concurrency: 2},
+	}
+	provider, err := NewGitHubProvider(cfg, newURLReader(cfg))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var mu sync.Mutex
+	results := make(map[string]ports.SourceResult)
+	err = provider.Provide(context.Background(), func(result ports.SourceResult) {
+		mu.Lock()
+		defer mu.Unlock()
+		results[result.Source] = result
+	})
+	if err != nil {
+		t.Fatalf("Provide() error = %v", err)
+	}
+
+	if len(results) != 3 {
+		t.Fatalf("got %d sources, want 3: %v", len(results), results)
+	}
+	if empty := results["github:acme/empty"]; empty.Err != nil || len(empty.Entities) != 0 {
+		t.Errorf("repository without a catalog file = %d entities, error %v, want none", len(empty.Entities), empty.Err)
+	}
+
+	payments, paymentsStatus := discoveredComponent(t, results["github:acme/payments"])
+	if payments.ReadmeContent != "# Payments" {
+		t.Errorf("payments README = %q", payments.ReadmeContent)
+	}
+	if got := string(payments.Repository.Tags); got != `[{"name":"v1.2.0","timestamp":"2026-02-02T10:00:00Z"}]` {
+		t.Errorf("payments tags = %s, want the published release only", got)
+	}
+	if payments.CI.LastRunStatus != "success" || payments.CI.PipelineURL != "https://github.com/acme/payments/actions/runs/7" {
+		t.Errorf("payments CI = %+v", payments.CI)
+	}
+	if payments.BaseImage != "golang:1.24" {
+		t.Errorf("payments base image = %q, want the first FROM", payments.BaseImage)
+	}
+	if payments.DeploymentTarget != "payments" || payments.ProjectURL != "https://github.com/acme/payments" {
+		t.Errorf("payments deployment target = %q, project URL = %q", payments.DeploymentTarget, payments.ProjectURL)
+	}
+	if !hasStatus(paymentsStatus, "warning", repositoryFileStatus) {
+		t.Errorf("payments status = %+v, want a warning for the file that failed to load", paymentsStatus)
+	}
+	if hasStatus(paymentsStatus, "info", readmeMissingStatus) {
+		t.Errorf("payments status = %+v, want no missing README", paymentsStatus)
+	}
+
+	billing, billingStatus := discoveredComponent(t, results["github:acme/billing"])
+	if got := string(billing.Repository.Tags); got != `[{"name":"v0.1.0"}]` {
+		t.Errorf("billing tags = %s, want the plain tags", got)
+	}
+	if billing.CI.LastRunStatus != "running" {
+		t.Errorf("billing CI status = %q, want running", billing.CI.LastRunStatus)
+	}
+	if billing.BaseImage != "node:22" {
+		t.Errorf("billing base image = %q, want the one of the lowercase dockerfile", billing.BaseImage)
+	}
+	if !hasStatus(billingStatus, "info", readmeMissingStatus) {
+		t.Errorf("billing status = %+v, want a missing README", billingStatus)
+	}
+	if hasStatus(billingStatus, "warning", repositoryFileStatus) {
+		t.Errorf("billing status = %+v, want missing files not to be reported", billingStatus)
+	}
+}
+
+// discoveredComponent returns the spec and status of the single Component of a source.
+func discoveredComponent(t *testing.T, result ports.SourceResult) (entities.ComponentSpec, *entities.EntityStatus) {
+	t.Helper()
+	if result.Err != nil || len(result.Entities) != 1 {
+		t.Fatalf("%s = %d entities, error %v, want one", result.Source, len(result.Entities), result.Err)
+	}
+	entity := result.Entities[0]
+	want := "url:https://github.com/acme/" + entity.Metadata.Name + "/blob/main/catalog-info.yaml"
+	if got := entity.Metadata.Annotations[sourceLocationAnnotation]; got != want {
+		t.Errorf("%s source location = %v, want %s", result.Source, got, want)
+	}
+	var spec entities.ComponentSpec
+	if err := json.Unmarshal(entity.Spec, &spec); err != nil {
+		t.Fatal(err)
+	}
+	return spec, entity.Status
+}
+
+// hasStatus reports whether a status has an item of the given level and type.
+func hasStatus(status *entities.EntityStatus, level, itemType string) bool {
+	if status == nil {
+		return false
+	}
+	for _, item := range status.Items {
+		if item.Level == level && item.Type == itemType {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,48 @@
+package application
+
+import (
+	"errors"
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+	"net/http"
+)
+
+// scanPages visits every item of a paginated GitLab listing, following keyset
+// links when the API returns them and page numbers otherwise. At most maxItems
+// items are visited (zero means no limit); truncated reports whether the listing
+// had more items than that. Returning false from visit stops the scan early.
+func scanPages[T any](maxItems int, list func(p gitlab.PaginationOptionFunc) ([]T, *gitlab.Response, error), visit func(T) bool) (truncated bool, err error) {
+	count := 0
+	for item, err := range gitlab.Scan2(list) {
+		if err != nil {
+			return false, err
+		}
+		if maxItems > 0 && count >= maxItems {
+			return true, nil
+		}
+		count++
+		if !visit(item) {
+			return false, nil
+		}
+	}
+	return false, nil
+}
+
+// collectPages gathers the items of a paginated GitLab listing, up to maxItems.
+func collectPages[T any](maxItems int, list func(p gitlab.PaginationOptionFunc) ([]T, *gitlab.Response, error)) ([]T, bool, error) {
+	var items []T
+	truncated, err := scanPages(maxItems, list, func(item T) bool {
+		items = append(items, item)
+		return true
+	})
+	return items, truncated, err
+}
+
+// isKeysetUnsupported reports whether GitLab rejected a keyset pagination request,
+// which older instances and some endpoints do.
+func isKeysetUnsupported(err error) bool {
+	var errResp *gitlab.ErrorResponse
+	if !errors.As(err, &errResp) {
+		return false
+	}
+	return errResp.HasStatusCode(http.StatusBadRequest) || errResp.HasStatusCode(http.StatusMethodNotAllowed)
+}
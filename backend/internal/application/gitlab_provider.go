@@ -0,0 +1,507 @@
+package application
+
+import (
+	"context"
+	"dev-compass/internal/domain/entities"
+	"dev-compass/internal/domain/ports"
+	"dev-compass/internal/infrastructure/config"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+	"io/fs"
+	"log"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// GitLabProvider discovers entities from the catalog files of every project in a GitLab group.
+type GitLabProvider struct {
+	cfg    *config.Config
+	urls   *urlReader
+	client *gitlab.Client
+}
+
+// NewGitLabProvider creates a new GitLabProvider.
+func NewGitLabProvider(cfg *config.Config, urls *urlReader) (*GitLabProvider, error) {
+	if cfg.GitLab.Token == "" {
+		return nil, fmt.Errorf("GitLab token is not configured")
+	}
+	client, err := gitlab.NewClient(cfg.GitLab.Token)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create GitLab client: %w", err)
+	}
+	return &GitLabProvider{
+		cfg:    cfg,
+		urls:   urls,
+		client: client,
+	}, nil
+}
+
+// Name returns the provider name.
+func (p *GitLabProvider) Name() string {
+	return "gitlab"
+}
+
+// source returns the source identifier of a project.
+func (p *GitLabProvider) source(pathWithNamespace string) string {
+	return p.Name() + ":" + pathWithNamespace
+}
+
+// Provide scans every project of the configured group. Projects that cannot be
+// read are reported as failed so their entities survive until the next run.
+func (p *GitLabProvider) Provide(ctx context.Context, emit func(ports.SourceResult)) error {
+	log.Println("INFO: Starting GitLab discovery process...")
+
+	groupToScan := p.groupToScan()
+	if groupToScan == "" {
+		return fmt.Errorf("GITLAB_GROUP_TO_SCAN is not configured")
+	}
+
+	projects, truncated, err := p.listGroupProjects(ctx, groupToScan)
+	if err != nil {
+		return fmt.Errorf("failed to list projects in group %s: %w", groupToScan, err)
+	}
+	if truncated {
+		log.Printf("WARN: Group %s has more than %d projects, the rest will not be scanned. Raise GITLAB_MAX_LIST_ITEMS to cover them.", groupToScan, p.cfg.GitLab.MaxListItems)
+	}
+
+	log.Printf("INFO: Found %d projects to scan with %d workers.", len(projects), p.cfg.Discovery.Concurrency)
+	forEachConcurrently(ctx, p.cfg.Discovery.Concurrency, projects, func(project *gitlab.Project) {
+		discovered, err := p.scanProject(ctx, project)
+		emit(ports.SourceResult{Source: p.source(project.PathWithNamespace), Entities: discovered, Err: err})
+	})
+
+	// Projects that were never scanned must not be mistaken for deleted ones.
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("GitLab discovery was cancelled: %w", err)
+	}
+	if truncated {
+		return fmt.Errorf("group %s has more than %d projects", groupToScan, p.cfg.GitLab.MaxListItems)
+	}
+
+	log.Println("INFO: GitLab discovery process finished.")
+	return nil
+}
+
+// Refresh rescans a single GitLab project. It is used by webhooks to pick up
+// changes without waiting for a full discovery.
+func (p *GitLabProvider) Refresh(ctx context.Context, pathWithNamespace string) ports.SourceResult {
+	source := p.source(pathWithNamespace)
+	project, _, err := p.client.Projects.GetProject(pathWithNamespace, nil, gitlab.WithContext(ctx))
+	switch {
+	case errors.Is(err, gitlab.ErrNotFound):
+		log.Printf("INFO: Project %s no longer exists, removing its entities.", pathWithNamespace)
+		return ports.SourceResult{Source: source}
+	case err != nil:
+		return ports.SourceResult{Source: source, Err: fmt.Errorf("failed to get project %s: %w", pathWithNamespace, err)}
+	}
+
+	discovered, err := p.scanProject(ctx, project)
+	return ports.SourceResult{Source: source, Entities: discovered, Err: err}
+}
+
+// InScannedGroup reports whether a project path belongs to the configured group or one of its subgroups.
+func (p *GitLabProvider) InScannedGroup(pathWithNamespace string) bool {
+	group := strings.ToLower(strings.Trim(p.groupToScan(), "/"))
+	if group == "" {
+		return false
+	}
+	return strings.HasPrefix(strings.ToLower(pathWithNamespace), group+"/")
+}
+
+// groupToScan returns the configured group path, accepting either a path or a full group URL.
+func (p *GitLabProvider) groupToScan() string {
+	groupToScan := p.cfg.GitLab.GroupToScan
+	u, err := url.Parse(groupToScan)
+	if err == nil && u.Scheme != "" && u.Host != "" {
+		groupToScan = strings.TrimPrefix(u.Path, "/")
+	}
+	return groupToScan
+}
+
+// listGroupProjects lists every project of a group and its subgroups, using keyset
+// pagination when the GitLab instance supports it for this endpoint.
+func (p *GitLabProvider) listGroupProjects(ctx context.Context, group string) ([]*gitlab.Project, bool, error) {
+	list := func(opts *gitlab.ListGroupProjectsOptions) func(page gitlab.PaginationOptionFunc) ([]*gitlab.Project, *gitlab.Response, error) {
+		return func(page gitlab.PaginationOptionFunc) ([]*gitlab.Project, *gitlab.Response, error) {
+			return p.client.Groups.ListGroupProjects(group, opts, page, gitlab.WithContext(ctx))
+		}
+	}
+
+	projects, truncated, err := collectPages(p.cfg.GitLab.MaxListItems, list(&gitlab.ListGroupProjectsOptions{
+		IncludeSubGroups: gitlab.Ptr(true),
+		OrderBy:          gitlab.Ptr("id"),
+		Sort:             gitlab.Ptr("asc"),
+		ListOptions:      gitlab.ListOptions{Pagination: "keyset", PerPage: 100},
+	}))
+	if err != nil && isKeysetUnsupported(err) {
+		log.Printf("DEBUG: Keyset pagination not available for group %s, falling back to offset pagination.", group)
+		projects, truncated, err = collectPages(p.cfg.GitLab.MaxListItems, list(&gitlab.ListGroupProjectsOptions{
+			IncludeSubGroups: gitlab.Ptr(true),
+			ListOptions:      gitlab.ListOptions{PerPage: 100},
+		}))
+	}
+	return projects, truncated, err
+}
+
+// scanProject reads the catalog files of a single GitLab project. A project
+// without a catalog file yields no entities and no error.
+func (p *GitLabProvider) scanProject(ctx context.Context, project *gitlab.Project) ([]*entities.Entity, error) {
+	log.Printf("INFO: Scanning project: %s", project.PathWithNamespace)
+
+	readFile := func(ctx context.Context, target string) ([]byte, error) {
+		filename, err := repositoryPath(target)
+		if err != nil {
+			return nil, err
+		}
+		file, _, err := p.client.RepositoryFiles.GetFile(project.ID, filename, &gitlab.GetFileOptions{
+			Ref: gitlab.Ptr(project.DefaultBranch),
+		}, gitlab.WithContext(ctx))
+		if err != nil {
+			if errors.Is(err, gitlab.ErrNotFound) {
+				return nil, fmt.Errorf("%s: %w", filename, fs.ErrNotExist)
+			}
+			return nil, err
+		}
+		return base64.StdEncoding.DecodeString(file.Content)
+	}
+
+	roots, err := p.findCatalogFiles(ctx, project, readFile)
+	if err != nil {
+		return nil, err
+	}
+	if len(roots) == 0 {
+		log.Printf("DEBUG: Could not find a catalog file in %s", project.PathWithNamespace)
+		return nil, nil
+	}
+
+	tree := newCatalogTree(withRemoteTargets(p.urls, readFile))
+	for _, root := range roots {
+		if err := tree.add(ctx, root.path, root.content); err != nil {
+			return nil, fmt.Errorf("failed to read catalog of %s: %w", project.PathWithNamespace, err)
+		}
+	}
+	documents := tree.documents
+
+	var discovered []*entities.Entity
+	for i := range documents {
+		annotations := documents[i].Metadata.Annotations
+		finalEntity, err := processEntity(ctx, &documents[i], func(ctx context.Context, spec *entities.ComponentSpec, status *statusCollector) {
+			environments := deploymentEnvironments(annotations)
+			// A component may live in a different project than its catalog file.
+			if slug := annotations[gitlabProjectSlugAnnotation]; slug != "" && !strings.EqualFold(slug, project.PathWithNamespace) {
+				p.enrichFromProject(ctx, slug, spec, environments, status)
+				return
+			}
+			p.enrichComponentSpec(ctx, spec, project, environments, status)
+			spec.ProjectURL = project.WebURL
+		}, tree.read)
+		if err != nil {
+			return nil, fmt.Errorf("failed to process entity from %s: %w", project.PathWithNamespace, err)
+		}
+		location, filePath := p.sourceLocation(project, documents[i].location)
+		annotateSource(finalEntity, location, filePath)
+		discovered = append(discovered, finalEntity)
+	}
+	return discovered, nil
+}
+
+// catalogFile is a catalog file read from a repository.
+type catalogFile struct {
+	path    string
+	content []byte
+}
+
+// findCatalogFiles returns the catalog files of a project. Without GITLAB_CATALOG_PATHS
+// only the first catalog file found at the repository root is read. Otherwise every
+// file matching one of the configured paths is, where a path may be a glob pattern
+// such as "services/*/catalog-info.yaml".
+func (p *GitLabProvider) findCatalogFiles(ctx context.Context, project *gitlab.Project, readFile catalogReader) ([]catalogFile, error) {
+	if len(p.cfg.GitLab.CatalogPaths) == 0 {
+		possibleFilenames := []string{"catalog-info.yaml", "catalog-info.yml", "devcompass.yaml", "devcompass.yml"}
+		for _, filename := range possibleFilenames {
+			data, err := readFile(ctx, filename)
+			if err == nil {
+				return []catalogFile{{path: filename, content: data}}, nil
+			}
+			if !errors.Is(err, fs.ErrNotExist) {
+				return nil, fmt.Errorf("failed to read %s in %s: %w", filename, project.PathWithNamespace, err)
+			}
+		}
+		return nil, nil
+	}
+
+	var filenames []string
+	var repositoryFiles []string
+	listed := false
+	seen := make(map[string]bool)
+	for _, pattern := range p.cfg.GitLab.CatalogPaths {
+		pattern = strings.TrimPrefix(pattern, "/")
+		if !strings.ContainsAny(pattern, "*?[") {
+			if !seen[pattern] {
+				seen[pattern] = true
+				filenames = append(filenames, pattern)
+			}
+			continue
+		}
+
+		// Glob patterns need the file list, which is fetched once per project.
+		if !listed {
+			var err error
+			repositoryFiles, err = p.listRepositoryFiles(ctx, project)
+			if err != nil {
+				return nil, err
+			}
+			listed = true
+		}
+		for _, filename := range repositoryFiles {
+			matched, err := path.Match(pattern, filename)
+			if err != nil {
+				return nil, fmt.Errorf("invalid catalog path pattern %q: %w", pattern, err)
+			}
+			if matched && !seen[filename] {
+				seen[filename] = true
+				filenames = append(filenames, filename)
+			}
+		}
+	}
+
+	var files []catalogFile
+	for _, filename := range filenames {
+		data, err := readFile(ctx, filename)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s in %s: %w", filename, project.PathWithNamespace, err)
+		}
+		files = append(files, catalogFile{path: filename, content: data})
+	}
+	return files, nil
+}
+
+// listRepositoryFiles lists the path of every file in the default branch of a project.
+func (p *GitLabProvider) listRepositoryFiles(ctx context.Context, project *gitlab.Project) ([]string, error) {
+	var files []string
+	truncated, err := scanPages(p.cfg.GitLab.MaxListItems, func(page gitlab.PaginationOptionFunc) ([]*gitlab.TreeNode, *gitlab.Response, error) {
+		return p.client.Repositories.ListTree(project.ID, &gitlab.ListTreeOptions{
+			Ref:         gitlab.Ptr(project.DefaultBranch),
+			Recursive:   gitlab.Ptr(true),
+			ListOptions: gitlab.ListOptions{PerPage: 100},
+		}, page, gitlab.WithContext(ctx))
+	}, func(node *gitlab.TreeNode) bool {
+		if node.Type == "blob" {
+			files = append(files, node.Path)
+		}
+		return true
+	})
+	if err != nil {
+		// An empty repository has no tree at all.
+		if errors.Is(err, gitlab.ErrNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to list files of %s: %w", project.PathWithNamespace, err)
+	}
+	if truncated {
+		// Missing files would look like deleted catalog files.
+		return nil, fmt.Errorf("repository %s has more than %d files, raise GITLAB_MAX_LIST_ITEMS to cover them", project.PathWithNamespace, p.cfg.GitLab.MaxListItems)
+	}
+	return files, nil
+}
+
+// sourceLocation returns the location and repository path annotations of a catalog file read from a project.
+func (p *GitLabProvider) sourceLocation(project *gitlab.Project, location string) (string, string) {
+	if isRemoteTarget(location) {
+		return "url:" + location, ""
+	}
+	return "url:" + project.WebURL + "/-/blob/" + project.DefaultBranch + "/" + location, location
+}
+
+// enrichFromProject populates a ComponentSpec from the GitLab project at a path, e.g. "group/project".
+func (p *GitLabProvider) enrichFromProject(ctx context.Context, pathWithNamespace string, spec *entities.ComponentSpec, environments []string, status *statusCollector) {
+	project, _, err := p.client.Projects.GetProject(pathWithNamespace, nil, gitlab.WithContext(ctx))
+	if err != nil {
+		log.Printf("WARN: Could not get project %s: %v", pathWithNamespace, err)
+		status.add("warning", projectUnavailableStatus, "could not get GitLab project %s: %v", pathWithNamespace, err)
+		return
+	}
+	p.enrichComponentSpec(ctx, spec, project, environments, status)
+	spec.ProjectURL = project.WebURL
+}
+
+// enrichComponentSpec populates a ComponentSpec with data fetched from the GitLab API.
+// Deployments are read from the given environments.
+func (p *GitLabProvider) enrichComponentSpec(ctx context.Context, spec *entities.ComponentSpec, project *gitlab.Project, environmentNames []string, status *statusCollector) {
+	// --- Fetch Deployments for Environments --- //
+	var deployments []entities.Deployment
+	jobNameRegex := regexp.MustCompile(`\s\[(\d+)\]$`)
+
+	for _, envName := range environmentNames {
+		opts := &gitlab.ListProjectDeploymentsOptions{
+			Environment: gitlab.Ptr(envName),
+			Status:      gitlab.Ptr("success"),
+			OrderBy:     gitlab.Ptr("id"),
+			Sort:        gitlab.Ptr("desc"),
+			ListOptions: gitlab.ListOptions{PerPage: 100},
+		}
+		// Find the latest deployment for each entidad in this environment, up to a limit.
+		// Pages are fetched lazily, so the scan stops as soon as enough deployments were found.
+		latestForEntidad := make(map[string]bool)
+		deploymentsInEnvCount := 0
+		scannedDeployments := 0
+
+		_, err := scanPages(p.cfg.GitLab.MaxListItems, func(page gitlab.PaginationOptionFunc) ([]*gitlab.Deployment, *gitlab.Response, error) {
+			return p.client.Deployments.ListProjectDeployments(project.ID, opts, page, gitlab.WithContext(ctx))
+		}, func(d *gitlab.Deployment) bool {
+			scannedDeployments++
+			if deploymentsInEnvCount >= 10 {
+				return false // Stop after finding the 10 most recent unique deployments
+			}
+
+			if d.Deployable.ID == 0 || !strings.HasPrefix(d.Deployable.Name, "deploy") {
+				return true
+			}
+
+			log.Printf("TRACE: Processing job with name: '%s'", d.Deployable.Name)
+
+			// Try to parse an Entidad from the job name, but don't fail if it's not there.
+			matches := jobNameRegex.FindStringSubmatch(d.Deployable.Name)
+			entidad := ""
+			if len(matches) >= 2 {
+				entidad = matches[1]
+			}
+
+			// Create a unique key for the deployment to avoid duplicates if an entidad is not present
+			deploymentKey := entidad
+			if deploymentKey == "" {
+				deploymentKey = "global" // A key for non-entidad deployments
+			}
+
+			// Since the list is sorted by latest, the first one we see for a key is the one we want
+			if _, found := latestForEntidad[deploymentKey]; !found {
+				version := "N/A"
+				if d.Deployable.Tag {
+					version = d.Ref
+				} else if len(d.SHA) >= 7 {
+					version = d.SHA[:7]
+				}
+
+				deployments = append(deployments, entities.Deployment{
+					Environment: envName,
+					Version:     version,
+					Timestamp:   d.CreatedAt.String(),
+					Entidad:     entidad,
+				})
+
+				log.Printf("DEBUG: Project [%s] - Parsed deployment: Env=%s, Entidad=%s, Version=%s", project.PathWithNamespace, envName, entidad, version)
+
+				latestForEntidad[deploymentKey] = true
+				deploymentsInEnvCount++ // Increment counter
+			}
+			return true
+		})
+		log.Printf("INFO: Project [%s] - Env [%s]: Processed %d successful deployments.", project.PathWithNamespace, envName, scannedDeployments)
+
+		if err != nil {
+			log.Printf("WARN: Could not fetch deployments for env %s in project %s: %v", envName, project.PathWithNamespace, err)
+			status.add("warning", deploymentsUnavailableStatus, "could not fetch deployments for environment %s: %v", envName, err)
+			continue
+		}
+	}
+	spec.Deployments = deployments
+
+	// --- Fetch README.md ---
+	readmeFile, _, err := p.client.RepositoryFiles.GetFile(project.ID, "README.md", &gitlab.GetFileOptions{Ref: gitlab.Ptr(project.DefaultBranch)}, gitlab.WithContext(ctx))
+	if err != nil {
+		log.Printf("DEBUG: No README.md found for %s, continuing without it.", project.PathWithNamespace)
+		status.add("info", readmeMissingStatus, "no README.md found on branch %s", project.DefaultBranch)
+	} else {
+		decodedReadme, err := base64.StdEncoding.DecodeString(readmeFile.Content)
+		if err != nil {
+			log.Printf("ERROR: Failed to decode README.md for %s: %v", project.PathWithNamespace, err)
+			status.add("warning", readmeMissingStatus, "README.md could not be decoded: %v", err)
+		} else {
+			spec.ReadmeContent = string(decodedReadme)
+		}
+	}
+
+	// --- Enrich with dynamic data from GitLab API ---
+	repoAPITags, _, err := p.client.Tags.ListTags(project.ID, &gitlab.ListTagsOptions{
+		ListOptions: gitlab.ListOptions{PerPage: maxRepositoryTags},
+		OrderBy:     gitlab.Ptr("updated"),
+		Sort:        gitlab.Ptr("desc"),
+	}, gitlab.WithContext(ctx))
+	if err != nil {
+		log.Printf("WARN: Could not fetch tags for project %s: %v", project.PathWithNamespace, err)
+		status.add("warning", tagsUnavailableStatus, "could not fetch the repository tags: %v", err)
+	} else {
+		var collectedTags []map[string]string
+		for _, tag := range repoAPITags {
+			if tag.Commit != nil {
+				collectedTags = append(collectedTags, map[string]string{
+					"name":      tag.Name,
+					"timestamp": tag.Commit.CreatedAt.String(),
+				})
+			}
+		}
+		if spec.Repository.Tags, err = json.Marshal(collectedTags); err != nil {
+			status.add("warning", tagsUnavailableStatus, "could not store the repository tags: %v", err)
+		}
+
+		if len(repoAPITags) > 0 {
+			latestTag := repoAPITags[0]
+			if latestTag.Commit != nil {
+				log.Printf("DEBUG: Found latest tag '%s', finding pipeline for commit SHA %s", latestTag.Name, latestTag.Commit.ID)
+				pipelines, _, err := p.client.Pipelines.ListProjectPipelines(project.ID, &gitlab.ListProjectPipelinesOptions{
+					SHA:         gitlab.Ptr(latestTag.Commit.ID),
+					ListOptions: gitlab.ListOptions{PerPage: 1},
+				}, gitlab.WithContext(ctx))
+				if err != nil {
+					log.Printf("WARN: Could not fetch pipeline list for commit %s: %v", latestTag.Commit.ID, err)
+					status.add("warning", pipelineUnavailableStatus, "could not fetch the pipelines of tag %s: %v", latestTag.Name, err)
+				} else if len(pipelines) > 0 {
+					basicPipeline := pipelines[0]
+					detailedPipeline, _, err := p.client.Pipelines.GetPipeline(project.ID, basicPipeline.ID, gitlab.WithContext(ctx))
+					if err != nil {
+						log.Printf("WARN: Could not get detailed pipeline for ID %d, falling back to basic status: %v", basicPipeline.ID, err)
+						status.add("info", pipelineUnavailableStatus, "pipeline %d details could not be fetched, its basic status is shown: %v", basicPipeline.ID, err)
+						spec.CI.LastRunStatus = basicPipeline.Status
+						spec.CI.PipelineURL = basicPipeline.WebURL
+					} else {
+						finalStatus := detailedPipeline.Status
+						if detailedPipeline.DetailedStatus != nil && strings.Contains(detailedPipeline.DetailedStatus.Label, "warning") {
+							finalStatus = "warning"
+							log.Printf("DEBUG: Overriding status to 'warning' based on detailed status label: '%s'", detailedPipeline.DetailedStatus.Label)
+						}
+						spec.CI.LastRunStatus = finalStatus
+						spec.CI.PipelineURL = detailedPipeline.WebURL
+						log.Printf("DEBUG: Found pipeline %d with final status '%s' for latest tag", detailedPipeline.ID, finalStatus)
+					}
+				}
+			}
+		}
+	}
+
+	// --- Enrich with CI/CD file contents ---
+	getFileContent := func(filename string) (string, error) {
+		log.Printf("DEBUG: Attempting to read file '%s' for project '%s'...", filename, project.PathWithNamespace)
+		file, _, err := p.client.RepositoryFiles.GetFile(project.ID, filename, &gitlab.GetFileOptions{
+			Ref: gitlab.Ptr(project.DefaultBranch),
+		}, gitlab.WithContext(ctx))
+		if err != nil {
+			log.Printf("DEBUG: File '%s' not found for project '%s'.", filename, project.PathWithNamespace)
+			return "", err
+		}
+		decoded, err := base64.StdEncoding.DecodeString(file.Content)
+		if err != nil {
+			return "", fmt.Errorf("failed to decode %s: %w", filename, err)
+		}
+		return string(decoded), nil
+	}
+
+	applyRepositoryFiles(spec, project.Name, getFileContent, status)
+}
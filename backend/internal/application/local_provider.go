@@ -0,0 +1,248 @@
+package application
+
+import (
+	"bytes"
+	"context"
+	"dev-compass/internal/domain/entities"
+	"dev-compass/internal/domain/ports"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// LocalProvider discovers entities from a directory tree of cloned or bare git
+// repositories. It needs no network access, which makes it usable in air-gapped
+// environments and for developing discovery without a GitLab token.
+type LocalProvider struct {
+	root        string
+	concurrency int
+	urls        *urlReader
+}
+
+// NewLocalProvider creates a new LocalProvider that walks the given root directory.
+func NewLocalProvider(root string, concurrency int, urls *urlReader) (*LocalProvider, error) {
+	if root == "" {
+		return nil, fmt.Errorf("DISCOVERY_LOCAL_ROOT is not configured")
+	}
+	return &LocalProvider{root: root, concurrency: concurrency, urls: urls}, nil
+}
+
+// Name returns the provider name.
+func (p *LocalProvider) Name() string {
+	return "local"
+}
+
+// localRepository gives access to the files and tags of a repository on disk,
+// either a working tree or a bare repository.
+type localRepository struct {
+	dir  string
+	bare bool
+}
+
+// Provide walks the root directory and scans every repository found in it.
+// Repositories are not searched for nested repositories.
+func (p *LocalProvider) Provide(ctx context.Context, emit func(ports.SourceResult)) error {
+	log.Printf("INFO: Starting local discovery in %s...", p.root)
+
+	var repos []localRepository
+	err := filepath.WalkDir(p.root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if path != p.root && (strings.HasPrefix(d.Name(), ".") || d.Name() == "node_modules") {
+			return filepath.SkipDir
+		}
+
+		switch {
+		case exists(filepath.Join(path, ".git")):
+			repos = append(repos, localRepository{dir: path})
+			return filepath.SkipDir
+		case isBareRepository(path):
+			repos = append(repos, localRepository{dir: path, bare: true})
+			return filepath.SkipDir
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to walk %s: %w", p.root, err)
+	}
+
+	log.Printf("INFO: Found %d local repositories to scan with %d workers.", len(repos), p.concurrency)
+	forEachConcurrently(ctx, p.concurrency, repos, func(repo localRepository) {
+		discovered, err := p.scanRepository(ctx, repo)
+		emit(ports.SourceResult{Source: p.Name() + ":" + p.relative(repo.dir), Entities: discovered, Err: err})
+	})
+
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("local discovery was cancelled: %w", err)
+	}
+
+	log.Println("INFO: Local discovery process finished.")
+	return nil
+}
+
+// scanRepository reads the catalog file of a local repository. A repository
+// without a catalog file yields no entities and no error.
+func (p *LocalProvider) scanRepository(ctx context.Context, repo localRepository) ([]*entities.Entity, error) {
+	name := p.relative(repo.dir)
+	log.Printf("INFO: Scanning local repository: %s", name)
+
+	readFile := func(ctx context.Context, target string) ([]byte, error) {
+		filename, err := repositoryPath(target)
+		if err != nil {
+			return nil, err
+		}
+		return repo.readFile(ctx, filename)
+	}
+
+	var root string
+	var content []byte
+	possibleFilenames := []string{"catalog-info.yaml", "catalog-info.yml", "devcompass.yaml", "devcompass.yml"}
+	for _, filename := range possibleFilenames {
+		data, err := readFile(ctx, filename)
+		if err == nil {
+			root, content = filename, data
+			break
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("failed to read %s in %s: %w", filename, name, err)
+		}
+	}
+
+	if content == nil {
+		log.Printf("DEBUG: Could not find a catalog file in %s", name)
+		return nil, nil
+	}
+
+	read := withRemoteTargets(p.urls, readFile)
+	documents, err := readCatalogTree(ctx, root, content, read)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read catalog of %s: %w", name, err)
+	}
+
+	var discovered []*entities.Entity
+	for i := range documents {
+		finalEntity, err := processEntity(ctx, &documents[i], func(ctx context.Context, spec *entities.ComponentSpec, status *statusCollector) {
+			p.enrichComponentSpec(ctx, spec, repo, status)
+		}, read)
+		if err != nil {
+			return nil, fmt.Errorf("failed to process entity from %s: %w", name, err)
+		}
+		if location := documents[i].location; isRemoteTarget(location) {
+			annotateSource(finalEntity, "url:"+location, "")
+		} else {
+			annotateSource(finalEntity, "file:"+filepath.Join(repo.dir, filepath.FromSlash(location)), location)
+		}
+		discovered = append(discovered, finalEntity)
+	}
+	return discovered, nil
+}
+
+// enrichComponentSpec populates a ComponentSpec from the files and tags of a local repository.
+func (p *LocalProvider) enrichComponentSpec(ctx context.Context, spec *entities.ComponentSpec, repo localRepository, status *statusCollector) {
+	name := p.relative(repo.dir)
+
+	if readme, err := repo.readFile(ctx, "README.md"); err != nil {
+		log.Printf("DEBUG: No README.md found for %s, continuing without it.", name)
+		status.add("info", readmeMissingStatus, "no README.md found in the repository")
+	} else {
+		spec.ReadmeContent = string(readme)
+	}
+
+	tags, err := repo.tags(ctx)
+	if err != nil {
+		log.Printf("WARN: Could not read tags for local repository %s: %v", name, err)
+		status.add("warning", tagsUnavailableStatus, "could not read the repository tags: %v", err)
+	} else if len(tags) > 0 {
+		if spec.Repository.Tags, err = json.Marshal(tags); err != nil {
+			status.add("warning", tagsUnavailableStatus, "could not store the repository tags: %v", err)
+		}
+	}
+
+	applyRepositoryFiles(spec, filepath.Base(strings.TrimSuffix(repo.dir, ".git")), func(filename string) (string, error) {
+		data, err := repo.readFile(ctx, filename)
+		return string(data), err
+	}, status)
+}
+
+// relative returns a repository path relative to the provider root, used as its source identifier.
+func (p *LocalProvider) relative(dir string) string {
+	rel, err := filepath.Rel(p.root, dir)
+	if err != nil {
+		return filepath.ToSlash(dir)
+	}
+	return filepath.ToSlash(rel)
+}
+
+// readFile reads a file from the working tree, or from HEAD for bare repositories.
+// A missing file is reported with an error matching fs.ErrNotExist.
+func (r localRepository) readFile(ctx context.Context, name string) ([]byte, error) {
+	if !r.bare {
+		return readLocalFile(filepath.Join(r.dir, filepath.FromSlash(name)))
+	}
+
+	if _, err := r.git(ctx, "cat-file", "-e", "HEAD:"+name); err != nil {
+		return nil, fmt.Errorf("%s: %w", name, fs.ErrNotExist)
+	}
+	return r.git(ctx, "show", "HEAD:"+name)
+}
+
+// tags returns the newest tags of the repository, most recent first, in the same shape as GitLab tags.
+func (r localRepository) tags(ctx context.Context) ([]map[string]string, error) {
+	out, err := r.git(ctx, "for-each-ref", "--sort=-creatordate", fmt.Sprintf("--count=%d", maxRepositoryTags), "--format=%(refname:short)%09%(creatordate:iso-strict)", "refs/tags")
+	if err != nil {
+		return nil, err
+	}
+
+	var tags []map[string]string
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		name, timestamp, found := strings.Cut(line, "\t")
+		if !found || name == "" {
+			continue
+		}
+		tags = append(tags, map[string]string{
+			"name":      name,
+			"timestamp": timestamp,
+		})
+	}
+	return tags, nil
+}
+
+// git runs a git command against the repository.
+func (r localRepository) git(ctx context.Context, args ...string) ([]byte, error) {
+	if r.bare {
+		args = append([]string{"--git-dir", r.dir}, args...)
+	} else {
+		args = append([]string{"-C", r.dir}, args...)
+	}
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git %s: %w: %s", args[2], err, strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
+
+// isBareRepository reports whether a directory has the layout of a bare git repository.
+func isBareRepository(dir string) bool {
+	return exists(filepath.Join(dir, "HEAD")) && exists(filepath.Join(dir, "objects")) && exists(filepath.Join(dir, "refs"))
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
@@ -0,0 +1,155 @@
+package application
+
+import (
+	"context"
+	"dev-compass/internal/domain/entities"
+	"dev-compass/internal/domain/ports"
+	"dev-compass/internal/infrastructure/config"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+// runGit runs a git command in dir, with a fixed identity and dates.
+func runGit(t *testing.T, dir, date string, args ...string) {
+	t.Helper()
+	cmd := exec.Command("git", append([]string{"-C", dir, "-c", "user.name=Test", "-c", "user.email=test@example.com", "-c", "init.defaultBranch=main"}, args...)...)
+	cmd.Env = append(os.Environ(), "GIT_AUTHOR_DATE="+date, "GIT_COMMITTER_DATE="+date)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %v: %v: %s", args, err, out)
+	}
+}
+
+// writeFiles writes files relative to dir.
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// componentCatalog returns a catalog file declaring a single Component.
+func componentCatalog(name string) string {
+	return "apiVersion: backstage.io/v1alpha1\nkind: Component\nmetadata:\n  name: " + name + "\nspec:\n  type: service\n  lifecycle: production\n  owner: team-a\n"
+}
+
+func TestLocalProviderDiscoversAndEnrichesComponents(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	root := t.TempDir()
+
+	// A working tree with more tags than are kept.
+	orders := filepath.Join(root, "team", "orders")
+	writeFiles(t, orders, map[string]string{
+		"catalog-info.yaml": componentCatalog("orders"),
+		"README.md":         "# Orders",
+		"Dockerfile":        "FROM golang:1.24 AS build\nFROM alpine:3.20\n",
+	})
+	runGit(t, orders, "2026-01-01T00:00:00Z", "init", "-q")
+	runGit(t, orders, "2026-01-01T00:00:00Z", "add", "-A")
+	runGit(t, orders, "2026-01-01T00:00:00Z", "commit", "-qm", "initial")
+	for i := 1; i <= maxRepositoryTags+2; i++ {
+		runGit(t, orders, fmt.Sprintf("2026-02-%02dT00:00:00Z", i), "tag", "-a", fmt.Sprintf("v1.%d.0", i), "-m", "release")
+	}
+
+	// A bare repository, read from its HEAD.
+	source := t.TempDir()
+	writeFiles(t, source, map[string]string{
+		"catalog-info.yaml": componentCatalog("invoices"),
+		"dockerfile":        "FROM node:22\n",
+	})
+	runGit(t, source, "2026-01-01T00:00:00Z", "init", "-q")
+	runGit(t, source, "2026-01-01T00:00:00Z", "add", "-A")
+	runGit(t, source, "2026-01-01T00:00:00Z", "commit", "-qm", "initial")
+	runGit(t, root, "2026-01-01T00:00:00Z", "clone", "-q", "--bare", source, "invoices.git")
+
+	// A repository without a catalog file, and one in a hidden directory.
+	plain := filepath.Join(root, "plain")
+	writeFiles(t, plain, map[string]string{"README.md": "# Plain"})
+	runGit(t, plain, "2026-01-01T00:00:00Z", "init", "-q")
+	hidden := filepath.Join(root, ".cache", "hidden")
+	writeFiles(t, hidden, map[string]string{"catalog-info.yaml": componentCatalog("hidden")})
+	runGit(t, hidden, "2026-01-01T00:00:00Z", "init", "-q")
+
+	cfg := &config.Config{GitLab: &config.GitLab{}, GitHub: &config.GitHub{}, Discovery: &config.Discovery{}}
+	provider, err := NewLocalProvider(root, 2, newURLReader(cfg))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var mu sync.Mutex
+	results := make(map[string]ports.SourceResult)
+	err = provider.Provide(context.Background(), func(result ports.SourceResult) {
+		mu.Lock()
+		defer mu.Unlock()
+		results[result.Source] = result
+	})
+	if err != nil {
+		t.Fatalf("Provide() error = %v", err)
+	}
+
+	if len(results) != 3 {
+		t.Fatalf("got sources %v, want team/orders, invoices.git and plain", results)
+	}
+	if plain := results["local:plain"]; plain.Err != nil || len(plain.Entities) != 0 {
+		t.Errorf("repository without a catalog file = %d entities, error %v, want none", len(plain.Entities), plain.Err)
+	}
+
+	ordersSpec, ordersEntity := localComponent(t, results["local:team/orders"])
+	if ordersSpec.ReadmeContent != "# Orders" {
+		t.Errorf("orders README = %q", ordersSpec.ReadmeContent)
+	}
+	if ordersSpec.BaseImage != "golang:1.24" {
+		t.Errorf("orders base image = %q, want the first FROM", ordersSpec.BaseImage)
+	}
+	var tags []map[string]string
+	if err := json.Unmarshal(ordersSpec.Repository.Tags, &tags); err != nil {
+		t.Fatal(err)
+	}
+	if len(tags) != maxRepositoryTags {
+		t.Fatalf("orders has %d tags, want the %d newest", len(tags), maxRepositoryTags)
+	}
+	if first, last := tags[0]["name"], tags[len(tags)-1]["name"]; first != "v1.12.0" || last != "v1.3.0" {
+		t.Errorf("orders tags go from %s to %s, want v1.12.0 to v1.3.0", first, last)
+	}
+	if want := "file:" + filepath.Join(orders, "catalog-info.yaml"); ordersEntity.Metadata.Annotations[sourceLocationAnnotation] != want {
+		t.Errorf("orders source location = %v, want %s", ordersEntity.Metadata.Annotations[sourceLocationAnnotation], want)
+	}
+
+	invoicesSpec, invoicesEntity := localComponent(t, results["local:invoices.git"])
+	if invoicesSpec.BaseImage != "node:22" {
+		t.Errorf("invoices base image = %q, want the one of the lowercase dockerfile", invoicesSpec.BaseImage)
+	}
+	if invoicesSpec.DeploymentTarget != "invoices" {
+		t.Errorf("invoices deployment target = %q, want the repository name", invoicesSpec.DeploymentTarget)
+	}
+	if tags := string(invoicesSpec.Repository.Tags); tags != "" && tags != "null" {
+		t.Errorf("invoices tags = %s, want none", tags)
+	}
+	if !hasStatus(invoicesEntity.Status, "info", readmeMissingStatus) {
+		t.Errorf("invoices status = %+v, want a missing README", invoicesEntity.Status)
+	}
+}
+
+// localComponent returns the spec and entity of the single Component of a source.
+func localComponent(t *testing.T, result ports.SourceResult) (entities.ComponentSpec, *entities.Entity) {
+	t.Helper()
+	if result.Err != nil || len(result.Entities) != 1 {
+		t.Fatalf("%s = %d entities, error %v, want one", result.Source, len(result.Entities), result.Err)
+	}
+	var spec entities.ComponentSpec
+	if err := json.Unmarshal(result.Entities[0].Spec, &spec); err != nil {
+		t.Fatal(err)
+	}
+	return spec, result.Entities[0]
+}
@@ -0,0 +1,77 @@
+package application
+
+import (
+	"context"
+	"dev-compass/internal/domain/entities"
+	"dev-compass/internal/domain/ports"
+	"fmt"
+	"log"
+)
+
+// managedByLocationAnnotation records the registered location an entity was read from.
+const managedByLocationAnnotation = "devcompass.io/managed-by-location"
+
+// LocationProvider discovers entities from the locations registered through the API.
+type LocationProvider struct {
+	locations ports.LocationRepository
+	urls      *urlReader
+}
+
+// NewLocationProvider creates a new LocationProvider.
+func NewLocationProvider(locations ports.LocationRepository, urls *urlReader) *LocationProvider {
+	return &LocationProvider{locations: locations, urls: urls}
+}
+
+// Name returns the provider name.
+func (p *LocationProvider) Name() string {
+	return "location"
+}
+
+// source returns the source identifier of a registered location.
+func (p *LocationProvider) source(id string) string {
+	return p.Name() + ":" + id
+}
+
+// Provide reads every registered location.
+func (p *LocationProvider) Provide(ctx context.Context, emit func(ports.SourceResult)) error {
+	locations, err := p.locations.FindAll()
+	if err != nil {
+		return fmt.Errorf("failed to list registered locations: %w", err)
+	}
+
+	log.Printf("INFO: Found %d registered locations to read.", len(locations))
+	for _, location := range locations {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		discovered, err := p.readLocation(ctx, location)
+		emit(ports.SourceResult{Source: p.source(location.ID), Entities: discovered, Err: err})
+	}
+	return nil
+}
+
+// readLocation reads the catalog file of a registered location and every location it references.
+func (p *LocationProvider) readLocation(ctx context.Context, location entities.Location) ([]*entities.Entity, error) {
+	log.Printf("INFO: Reading registered location: %s", location.Target)
+	content, err := p.urls.read(ctx, location.Target)
+	if err != nil {
+		return nil, err
+	}
+
+	documents, err := readCatalogTree(ctx, location.Target, content, p.urls.read)
+	if err != nil {
+		return nil, err
+	}
+
+	var discovered []*entities.Entity
+	for i := range documents {
+		finalEntity, err := processEntity(ctx, &documents[i], nil, p.urls.read)
+		if err != nil {
+			return nil, fmt.Errorf("failed to process entity from %s: %w", location.Target, err)
+		}
+		finalEntity.Metadata.Annotations[managedByLocationAnnotation] = "url:" + location.Target
+		annotateSource(finalEntity, "url:"+documents[i].location, "")
+		discovered = append(discovered, finalEntity)
+	}
+	return discovered, nil
+}
@@ -0,0 +1,126 @@
+package application
+
+import (
+	"context"
+	"crypto/sha256"
+	"dev-compass/internal/domain/entities"
+	"dev-compass/internal/domain/ports"
+	"dev-compass/internal/infrastructure/config"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+var (
+	// ErrInvalidLocation is returned when a location target is not an HTTP(S) URL.
+	ErrInvalidLocation = errors.New("location target must be an http or https URL")
+	// ErrLocationExists is returned when the same target is registered twice.
+	ErrLocationExists = errors.New("location is already registered")
+	// ErrLocationUnreadable is returned when a location cannot be read at registration time.
+	ErrLocationUnreadable = errors.New("location could not be read")
+	// ErrLocationNotAllowed is returned when a location, or a file it references, is on a host that may not be read.
+	ErrLocationNotAllowed = errors.New("location host is not allowed")
+)
+
+// LocationService registers catalog files by hand, outside of the scanned groups.
+type LocationService struct {
+	locations      ports.LocationRepository
+	repo           ports.EntityRepository
+	provider       *LocationProvider
+	relations      ports.RelationRepository
+	validationMode string
+	lock           *CatalogLock
+}
+
+// NewLocationService creates a new LocationService. lock is the one discovery
+// reconciles under, so locations are never applied in the middle of a run.
+func NewLocationService(cfg *config.Config, locations ports.LocationRepository, repo ports.EntityRepository, relations ports.RelationRepository, lock *CatalogLock) *LocationService {
+	return &LocationService{
+		locations:      locations,
+		repo:           repo,
+		provider:       NewLocationProvider(locations, newURLReader(cfg)),
+		relations:      relations,
+		validationMode: cfg.Catalog.ValidationMode,
+		lock:           lock,
+	}
+}
+
+// GetAllLocations returns every registered location.
+func (s *LocationService) GetAllLocations() ([]entities.Location, error) {
+	return s.locations.FindAll()
+}
+
+// GetLocation returns a single registered location.
+func (s *LocationService) GetLocation(id string) (*entities.Location, error) {
+	return s.locations.FindByID(id)
+}
+
+// RegisterLocation reads the target once to make sure it is a valid catalog
+// file, stores it and ingests its entities right away.
+func (s *LocationService) RegisterLocation(ctx context.Context, target string) (*entities.Location, *DiscoveryReport, error) {
+	parsed, err := url.Parse(target)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return nil, nil, ErrInvalidLocation
+	}
+	if err := s.provider.urls.check(parsed); err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrLocationNotAllowed, err)
+	}
+
+	location := &entities.Location{ID: locationID(target), Target: target, CreatedAt: time.Now()}
+	if _, err := s.locations.FindByID(location.ID); err == nil {
+		return nil, nil, ErrLocationExists
+	} else if !errors.Is(err, ports.ErrNotFound) {
+		return nil, nil, err
+	}
+
+	discovered, err := s.provider.readLocation(ctx, *location)
+	if errors.Is(err, errHostNotAllowed) {
+		return nil, nil, fmt.Errorf("%w: %v", ErrLocationNotAllowed, err)
+	} else if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrLocationUnreadable, err)
+	}
+	if err := s.locations.Save(location); err != nil {
+		return nil, nil, fmt.Errorf("failed to save location: %w", err)
+	}
+
+	report, err := s.reconcile(location.ID, discovered)
+	if err != nil {
+		return location, nil, err
+	}
+	return location, report, nil
+}
+
+// UnregisterLocation removes a registered location and every entity read from it.
+func (s *LocationService) UnregisterLocation(id string) (*DiscoveryReport, error) {
+	if _, err := s.locations.FindByID(id); err != nil {
+		return nil, err
+	}
+	if err := s.locations.Delete(id); err != nil {
+		return nil, fmt.Errorf("failed to delete location: %w", err)
+	}
+
+	// Reporting the source as empty removes everything it produced.
+	return s.reconcile(id, nil)
+}
+
+// reconcile applies the entities of a location to the catalog, holding the catalog lock.
+func (s *LocationService) reconcile(id string, discovered []*entities.Entity) (*DiscoveryReport, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	rec, err := newReconciler(s.repo, s.relations, s.validationMode)
+	if err != nil {
+		return nil, err
+	}
+	rec.apply(s.provider.source(id), discovered)
+	report := rec.finish()
+	return &report, nil
+}
+
+// locationID derives a stable ID from the target, so registering the same URL twice is detected.
+func locationID(target string) string {
+	sum := sha256.Sum256([]byte(target))
+	return hex.EncodeToString(sum[:8])
+}
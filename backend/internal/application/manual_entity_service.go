@@ -0,0 +1,152 @@
+package application
+
+import (
+	"bytes"
+	"context"
+	"dev-compass/internal/domain/entities"
+	"dev-compass/internal/domain/ports"
+	"dev-compass/internal/infrastructure/config"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// manualSourcePrefix is the source prefix of entities written through the API.
+// No provider reports it, so discovery never removes them.
+const manualSourcePrefix = "manual:"
+
+var (
+	// ErrInvalidEntity is returned when the body of a write is not a valid entity.
+	ErrInvalidEntity = errors.New("invalid entity")
+	// ErrEntityMismatch is returned when the body describes another entity than the URL.
+	ErrEntityMismatch = errors.New("kind, namespace and name of the entity must match the URL")
+	// ErrEntityNotManual is returned when writing to an entity owned by discovery.
+	ErrEntityNotManual = errors.New("entity is managed by discovery and cannot be changed through the API")
+	// ErrPreconditionFailed is returned when the If-Match etag is not the current one.
+	ErrPreconditionFailed = errors.New("entity was changed since it was read")
+)
+
+// ManualEntityService creates, updates and deletes entities through the API,
+// for things no repository describes. Writes can be made conditional on the etag
+// of the stored entity, so concurrent editors don't overwrite each other.
+type ManualEntityService struct {
+	repo           ports.EntityRepository
+	relations      ports.RelationRepository
+	validationMode string
+	// lock is shared with every other service that reconciles the catalog. Held
+	// from the etag check to the end of the write, it also makes them atomic.
+	lock *CatalogLock
+}
+
+// NewManualEntityService creates a new ManualEntityService.
+func NewManualEntityService(cfg *config.Config, repo ports.EntityRepository, relations ports.RelationRepository, lock *CatalogLock) *ManualEntityService {
+	return &ManualEntityService{repo: repo, relations: relations, validationMode: cfg.Catalog.ValidationMode, lock: lock}
+}
+
+// PutEntity creates or replaces a manual entity from a JSON or YAML document.
+// When ifMatch is set, the entity must exist and have a matching etag. It
+// returns the stored entity and whether it was created.
+func (s *ManualEntityService) PutEntity(ctx context.Context, kind, namespace, name string, body []byte, ifMatch string) (*entities.Entity, bool, error) {
+	documents, err := decodeCatalogDocuments(bytes.NewReader(body))
+	if err != nil {
+		return nil, false, fmt.Errorf("%w: %v", ErrInvalidEntity, err)
+	}
+	if len(documents) != 1 {
+		return nil, false, fmt.Errorf("%w: expected a single document with a kind and a metadata.name", ErrInvalidEntity)
+	}
+	document := &documents[0]
+	withoutServerFields(document)
+
+	ref := entities.EntityRef(kind, namespace, name)
+	if entities.EntityRef(document.Kind, document.Metadata.Namespace, document.Metadata.Name) != ref {
+		return nil, false, ErrEntityMismatch
+	}
+
+	entity, err := processEntity(ctx, document, nil, nil)
+	if err != nil {
+		return nil, false, fmt.Errorf("%w: %v", ErrInvalidEntity, err)
+	}
+	if violations := schemaViolations(entity); violations != "" && s.validationMode == validationReject {
+		return nil, false, fmt.Errorf("%w: %s", ErrInvalidEntity, violations)
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	existing, err := s.checkWrite(ref, ifMatch)
+	if err != nil && !errors.Is(err, ports.ErrNotFound) {
+		return nil, false, err
+	}
+	if err := s.reconcile(ref, []*entities.Entity{entity}); err != nil {
+		return nil, false, err
+	}
+
+	stored, err := s.repo.FindByRef(ref)
+	if err != nil {
+		return nil, false, err
+	}
+	return stored, existing == nil, nil
+}
+
+// DeleteEntity removes a manual entity. When ifMatch is set, its etag must match.
+func (s *ManualEntityService) DeleteEntity(kind, namespace, name, ifMatch string) error {
+	ref := entities.EntityRef(kind, namespace, name)
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if _, err := s.checkWrite(ref, ifMatch); err != nil {
+		return err
+	}
+	// Reporting the source as empty removes the entity along with its relations.
+	return s.reconcile(ref, nil)
+}
+
+// checkWrite returns the stored entity after making sure it may be written to.
+// It returns ports.ErrNotFound, along with a nil entity, when it does not exist yet.
+func (s *ManualEntityService) checkWrite(ref, ifMatch string) (*entities.Entity, error) {
+	existing, err := s.repo.FindByRef(ref)
+	if err != nil {
+		if errors.Is(err, ports.ErrNotFound) && ifMatch != "" {
+			return nil, ErrPreconditionFailed
+		}
+		return nil, err
+	}
+	if !strings.HasPrefix(existing.Source, manualSourcePrefix) {
+		return existing, ErrEntityNotManual
+	}
+	if ifMatch != "" && !etagMatches(ifMatch, existing.Metadata.ETag) {
+		return existing, ErrPreconditionFailed
+	}
+	return existing, nil
+}
+
+// reconcile stores the entities of a manual source, the same way discovery does.
+func (s *ManualEntityService) reconcile(ref string, discovered []*entities.Entity) error {
+	rec, err := newReconciler(s.repo, s.relations, s.validationMode)
+	if err != nil {
+		return err
+	}
+	rec.apply(manualSourcePrefix+ref, discovered)
+	report := rec.finish()
+	if len(report.Errors) > 0 {
+		return errors.New(report.Errors[0].Error)
+	}
+	return nil
+}
+
+// withoutServerFields drops the fields the server maintains from a document, so
+// an entity read from the API can be sent back as is.
+func withoutServerFields(document *yamlEntity) {
+	raw, ok := document.raw.(map[string]interface{})
+	if !ok {
+		return
+	}
+	delete(raw, "source")
+	delete(raw, "status")
+	if metadata, ok := raw["metadata"].(map[string]interface{}); ok {
+		delete(metadata, "uid")
+		delete(metadata, "generation")
+		delete(metadata, "etag")
+	}
+}
@@ -0,0 +1,194 @@
+package application
+
+import (
+	"dev-compass/internal/domain/entities"
+	"dev-compass/internal/domain/ports"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// unresolvedOwnerStatus is the status item type used for owners missing from the catalog.
+const unresolvedOwnerStatus = "devcompass.io/unresolved-owner"
+
+// OwnedEntities lists everything owned by a group and its sub-groups.
+type OwnedEntities struct {
+	Group     string            `json:"group"`
+	SubGroups []string          `json:"subGroups"`
+	Entities  []entities.Entity `json:"entities"`
+}
+
+// OwnerService provides services related to Group and User entities and ownership.
+type OwnerService struct {
+	repo      ports.EntityRepository
+	relations ports.RelationRepository
+}
+
+// NewOwnerService creates a new OwnerService.
+func NewOwnerService(repo ports.EntityRepository, relations ports.RelationRepository) *OwnerService {
+	return &OwnerService{repo: repo, relations: relations}
+}
+
+// GetOwnedEntities returns every entity owned by a group or by any of its sub-groups.
+// Sub-groups are found both through spec.children and through spec.parent, and
+// owned entities through their ownedBy relations.
+func (s *OwnerService) GetOwnedEntities(namespace, name string) (*OwnedEntities, error) {
+	group, err := s.repo.FindByRef(entities.EntityRef("Group", namespace, name))
+	if err != nil {
+		return nil, err
+	}
+
+	allGroups, err := s.repo.Query(ports.EntityQuery{Kinds: []string{"Group"}})
+	if err != nil {
+		return nil, err
+	}
+
+	children := make(map[string][]string)
+	for _, entity := range allGroups.Entities {
+		var spec entities.GroupSpec
+		if err := json.Unmarshal(entity.Spec, &spec); err != nil {
+			log.Printf("ERROR: could not unmarshal spec for entity %s: %v", entity.Ref, err)
+			continue
+		}
+		for _, child := range spec.Children {
+			children[entity.Ref] = append(children[entity.Ref], groupRef(child))
+		}
+		if spec.Parent != "" {
+			parent := groupRef(spec.Parent)
+			children[parent] = append(children[parent], entity.Ref)
+		}
+	}
+
+	// Walk the hierarchy, guarding against cycles.
+	groups := map[string]bool{group.Ref: true}
+	queue := []string{group.Ref}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, child := range children[current] {
+			if !groups[child] {
+				groups[child] = true
+				queue = append(queue, child)
+			}
+		}
+	}
+
+	owned := &OwnedEntities{Group: group.Ref, SubGroups: []string{}, Entities: []entities.Entity{}}
+	for ref := range groups {
+		if ref != group.Ref {
+			owned.SubGroups = append(owned.SubGroups, ref)
+		}
+	}
+	sort.Strings(owned.SubGroups)
+
+	seen := make(map[string]bool)
+	for ref := range groups {
+		relations, err := s.relations.FindByTarget(ref)
+		if err != nil {
+			return nil, err
+		}
+		for _, relation := range relations {
+			if relation.Type != entities.RelationOwnedBy || seen[relation.SourceRef] {
+				continue
+			}
+			seen[relation.SourceRef] = true
+
+			entity, err := s.repo.FindByRef(relation.SourceRef)
+			if errors.Is(err, ports.ErrNotFound) {
+				continue
+			} else if err != nil {
+				return nil, err
+			}
+			owned.Entities = append(owned.Entities, *entity)
+		}
+	}
+	sort.Slice(owned.Entities, func(i, j int) bool { return owned.Entities[i].Ref < owned.Entities[j].Ref })
+	return owned, nil
+}
+
+// maxStatusAttempts is how many times an owner warning is written before giving
+// up, when the entity keeps changing in the meantime.
+const maxStatusAttempts = 3
+
+// resolveOwners flags every entity whose spec.owner does not match a Group or
+// User in the catalog with a warning, and clears the warning once it does.
+func resolveOwners(repo ports.EntityRepository) error {
+	allEntities, err := repo.FindAll()
+	if err != nil {
+		return fmt.Errorf("failed to load entities: %w", err)
+	}
+
+	known := make(map[string]bool)
+	for _, entity := range allEntities {
+		if entity.Kind == "Group" || entity.Kind == "User" {
+			known[entity.Ref] = true
+		}
+	}
+
+	for _, entity := range allEntities {
+		if err := updateOwnerStatus(repo, entity, known); err != nil {
+			log.Printf("ERROR: Failed to save status of entity %s: %v", entity.Ref, err)
+		}
+	}
+	return nil
+}
+
+// updateOwnerStatus stores the owner warning of an entity when it changed. Only
+// the status and etag are written, and only if the entity is still the one that
+// was read, so a write made in the meantime is never reverted: the entity is
+// read again and the warning worked out anew.
+func updateOwnerStatus(repo ports.EntityRepository, entity entities.Entity, known map[string]bool) error {
+	for attempt := 1; ; attempt++ {
+		var items []entities.StatusItem
+		if owner := specOwner(entity); owner != "" && !known[groupRef(owner)] {
+			items = append(items, entities.StatusItem{
+				Level:   "warning",
+				Type:    unresolvedOwnerStatus,
+				Message: fmt.Sprintf("owner %q does not match any Group or User in the catalog", owner),
+			})
+		}
+		status := entity.Status.WithItems(unresolvedOwnerStatus, items)
+		if reflect.DeepEqual(status, entity.Status) {
+			return nil
+		}
+
+		etag := entity.Metadata.ETag
+		entity.Status = status
+		entity.Metadata.ETag = entityETag(&entity)
+		err := repo.UpdateStatus(entity.Ref, status, etag, entity.Metadata.ETag)
+		if errors.Is(err, ports.ErrNotFound) {
+			return nil // Deleted in the meantime
+		}
+		if !errors.Is(err, ports.ErrConflict) || attempt == maxStatusAttempts {
+			return err
+		}
+
+		current, err := repo.FindByRef(entity.Ref)
+		if errors.Is(err, ports.ErrNotFound) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		entity = *current
+	}
+}
+
+// specOwner returns the spec.owner of an entity, if its kind has one.
+func specOwner(entity entities.Entity) string {
+	var spec struct {
+		Owner string `json:"owner"`
+	}
+	if err := json.Unmarshal(entity.Spec, &spec); err != nil {
+		return ""
+	}
+	return strings.TrimSpace(spec.Owner)
+}
+
+// groupRef returns the canonical reference of an owner or group reference. References without a kind point to a Group.
+func groupRef(ref string) string {
+	return refWithDefaultKind(ref, "Group")
+}
@@ -0,0 +1,67 @@
+package application
+
+import (
+	"dev-compass/internal/domain/entities"
+	"encoding/json"
+	"testing"
+)
+
+func TestUpdateOwnerStatusKeepsConcurrentWrites(t *testing.T) {
+	_, repo := newTestReconciler(t)
+	entity := testEntity("a", "read by discovery", "manual:component:default/a")
+	entity.Spec, _ = json.Marshal(map[string]string{"type": "service", "owner": "team-x"})
+	stampEntity(entity, nil)
+	if err := repo.Save(entity); err != nil {
+		t.Fatal(err)
+	}
+	snapshot := *entity
+
+	// A write committed after the snapshot was taken.
+	edited := *entity
+	edited.Metadata.Description = "edited through the API"
+	stampEntity(&edited, entity)
+	if err := repo.Save(&edited); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := updateOwnerStatus(repo, snapshot, map[string]bool{}); err != nil {
+		t.Fatal(err)
+	}
+
+	stored, err := repo.FindByRef(entity.Ref)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Metadata.Description != "edited through the API" {
+		t.Errorf("description = %q, the concurrent write was reverted", stored.Metadata.Description)
+	}
+	if stored.Status == nil || len(stored.Status.Items) != 1 || stored.Status.Items[0].Type != unresolvedOwnerStatus {
+		t.Errorf("status = %+v, want the unresolved owner warning", stored.Status)
+	}
+	if stored.Metadata.ETag == edited.Metadata.ETag || stored.Metadata.ETag != entityETag(stored) {
+		t.Errorf("etag = %q, want the etag of the entity with its new status", stored.Metadata.ETag)
+	}
+}
+
+func TestUpdateOwnerStatusClearsResolvedOwners(t *testing.T) {
+	_, repo := newTestReconciler(t)
+	entity := testEntity("a", "", "manual:component:default/a")
+	entity.Spec, _ = json.Marshal(map[string]string{"type": "service", "owner": "team-x"})
+	entity.Status = &entities.EntityStatus{Items: []entities.StatusItem{{Level: "warning", Type: unresolvedOwnerStatus, Message: "stale"}}}
+	stampEntity(entity, nil)
+	if err := repo.Save(entity); err != nil {
+		t.Fatal(err)
+	}
+
+	known := map[string]bool{entities.EntityRef("Group", entities.DefaultNamespace, "team-x"): true}
+	if err := updateOwnerStatus(repo, *entity, known); err != nil {
+		t.Fatal(err)
+	}
+	stored, err := repo.FindByRef(entity.Ref)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Status != nil {
+		t.Errorf("status = %+v, want none", stored.Status)
+	}
+}
@@ -0,0 +1,145 @@
+package application
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"sort"
+	"strings"
+)
+
+const (
+	// maxPlaceholderSize limits the size of a file pulled in by a placeholder.
+	maxPlaceholderSize = 1 << 20
+	// maxPlaceholderDepth limits how deep $json and $yaml files may nest placeholders of their own.
+	maxPlaceholderDepth = 5
+	// unresolvedPlaceholderStatus is the status item type used for placeholders that could not be resolved.
+	unresolvedPlaceholderStatus = "devcompass.io/unresolved-placeholder"
+)
+
+// placeholderResolver replaces placeholders in a catalog document with the content
+// of the file they point to. A placeholder is an object with a single key:
+//
+//	$text: ./docs/openapi.yaml   the content of the file, as a string
+//	$json: ./config.json         the content of the file, parsed as JSON
+//	$yaml: ./config.yaml         the content of the file, parsed as YAML
+//
+// Paths are resolved relative to the file the placeholder is written in. Files
+// pulled in with $json or $yaml may hold placeholders too, resolved relative to
+// themselves. A placeholder that cannot be resolved is replaced with null and
+// reported, without affecting the rest of the document.
+type placeholderResolver struct {
+	read     catalogReader
+	problems []string
+}
+
+// resolveSpecPlaceholders resolves every placeholder in the spec of a catalog document.
+// It returns a message per placeholder that could not be resolved.
+func resolveSpecPlaceholders(ctx context.Context, tempEntity *yamlEntity, read catalogReader) []string {
+	if tempEntity.Spec == nil {
+		return nil
+	}
+	r := &placeholderResolver{read: read}
+	var chain []string
+	if tempEntity.location != "" {
+		chain = []string{tempEntity.location}
+	}
+	tempEntity.Spec, _ = r.resolve(ctx, tempEntity.Spec, "/spec", tempEntity.location, chain).(map[string]interface{})
+	return r.problems
+}
+
+// resolve returns value with its placeholders replaced. base is the file value was
+// read from, and chain the files being resolved, used to detect cycles.
+func (r *placeholderResolver) resolve(ctx context.Context, value interface{}, pointer, base string, chain []string) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if key, target, ok := placeholder(v); ok {
+			resolved, err := r.resolvePlaceholder(ctx, key, target, pointer, base, chain)
+			if err != nil {
+				r.problems = append(r.problems, fmt.Sprintf("%s: %s placeholder could not be resolved: %v", pointer, key, err))
+				return nil
+			}
+			return resolved
+		}
+		// Keys are resolved in order, so problems are always reported in the same order.
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			v[key] = r.resolve(ctx, v[key], pointer+"/"+escapePointer(key), base, chain)
+		}
+		return v
+	case []interface{}:
+		for i, item := range v {
+			v[i] = r.resolve(ctx, item, fmt.Sprintf("%s/%d", pointer, i), base, chain)
+		}
+		return v
+	default:
+		return value
+	}
+}
+
+// resolvePlaceholder reads the file a placeholder points to and decodes it according to its key.
+func (r *placeholderResolver) resolvePlaceholder(ctx context.Context, key string, target interface{}, pointer, base string, chain []string) (interface{}, error) {
+	path, ok := target.(string)
+	if !ok || path == "" {
+		return nil, fmt.Errorf("the value must be a file path")
+	}
+	if r.read == nil {
+		return nil, fmt.Errorf("files are not available for this entity")
+	}
+
+	resolved := resolveLocationTarget(base, path)
+	for _, file := range chain {
+		if file == resolved {
+			return nil, fmt.Errorf("%s is part of a cycle: %s", path, strings.Join(append(chain, resolved), " -> "))
+		}
+	}
+	if len(chain) > maxPlaceholderDepth {
+		return nil, fmt.Errorf("placeholders are nested more than %d levels deep", maxPlaceholderDepth)
+	}
+
+	content, err := r.read(ctx, resolved)
+	if err != nil {
+		return nil, err
+	}
+	if len(content) > maxPlaceholderSize {
+		return nil, fmt.Errorf("%s is larger than %d bytes", path, maxPlaceholderSize)
+	}
+
+	var decoded interface{}
+	switch key {
+	case "$text":
+		return string(content), nil
+	case "$json":
+		if err := json.Unmarshal(content, &decoded); err != nil {
+			return nil, fmt.Errorf("%s is not valid JSON: %w", path, err)
+		}
+	case "$yaml":
+		if err := yaml.Unmarshal(content, &decoded); err != nil {
+			return nil, fmt.Errorf("%s is not valid YAML: %w", path, err)
+		}
+	}
+	return r.resolve(ctx, decoded, pointer, resolved, append(chain, resolved)), nil
+}
+
+// placeholder reports whether an object is a placeholder, returning its key and target.
+func placeholder(object map[string]interface{}) (string, interface{}, bool) {
+	if len(object) != 1 {
+		return "", nil, false
+	}
+	for _, key := range []string{"$text", "$json", "$yaml"} {
+		if target, ok := object[key]; ok {
+			return key, target, true
+		}
+	}
+	return "", nil, false
+}
+
+// escapePointer escapes a key for use in a JSON pointer.
+func escapePointer(key string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(key, "~", "~0"), "/", "~1")
+}
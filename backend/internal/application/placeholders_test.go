@@ -0,0 +1,64 @@
+package application
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestProcessEntityReportsUnresolvedPlaceholdersOnce(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"openapi.yaml": "openapi: 3.0.0\n",
+		"huge.yaml":    strings.Repeat("#", maxCatalogFileSize+1),
+	})
+	read := func(ctx context.Context, target string) ([]byte, error) {
+		return readLocalFile(filepath.FromSlash(target))
+	}
+
+	tests := []struct {
+		name       string
+		definition string
+		wantStatus []string
+	}{
+		{"resolved", "$text: ./openapi.yaml", nil},
+		{"missing file", "$text: ./missing.yaml", []string{unresolvedPlaceholderStatus}},
+		{"file too large", "$text: ./huge.yaml", []string{unresolvedPlaceholderStatus}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			catalog := "apiVersion: devcompass.io/v1alpha1\nkind: API\nmetadata:\n  name: orders\nspec:\n  type: openapi\n  lifecycle: production\n  owner: team-a\n  definition:\n    " + tt.definition + "\n"
+			root := filepath.ToSlash(filepath.Join(dir, "catalog-info.yaml"))
+			documents, err := readCatalogTree(context.Background(), root, []byte(catalog), read)
+			if err != nil {
+				t.Fatal(err)
+			}
+			entity, err := processEntity(context.Background(), &documents[0], nil, read)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got []string
+			if entity.Status != nil {
+				for _, item := range entity.Status.Items {
+					got = append(got, item.Type)
+				}
+			}
+			if strings.Join(got, ",") != strings.Join(tt.wantStatus, ",") {
+				t.Errorf("status = %+v, want items of types %v", entity.Status, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestReadLocalFileIsBounded(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "huge.yaml")
+	if err := os.WriteFile(name, make([]byte, maxCatalogFileSize+1), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readLocalFile(name); err == nil || !strings.Contains(err.Error(), "larger than") {
+		t.Errorf("readLocalFile() error = %v, want the file to be too large", err)
+	}
+}
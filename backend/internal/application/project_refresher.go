@@ -0,0 +1,94 @@
+package application
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+// projectRefreshTimeout bounds the refresh of a single project.
+const projectRefreshTimeout = 5 * time.Minute
+
+// ProjectRefresher refreshes GitLab projects in the background, one at a time.
+// Requests for a project that is already waiting are merged into the pending
+// refresh, so a burst of webhook events costs a single refresh per project.
+type ProjectRefresher struct {
+	discovery *DiscoveryService
+
+	mu      sync.Mutex
+	pending []string        // Projects waiting to be refreshed, oldest first
+	queued  map[string]bool // The projects in pending
+	wake    chan struct{}
+}
+
+// NewProjectRefresher creates a new ProjectRefresher. Start must be called for queued projects to be refreshed.
+func NewProjectRefresher(discovery *DiscoveryService) *ProjectRefresher {
+	return &ProjectRefresher{
+		discovery: discovery,
+		queued:    make(map[string]bool),
+		wake:      make(chan struct{}, 1),
+	}
+}
+
+// Start launches the worker that refreshes queued projects until ctx is done.
+func (r *ProjectRefresher) Start(ctx context.Context) {
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-r.wake:
+			}
+			for {
+				project, ok := r.next()
+				if !ok {
+					break
+				}
+				r.refresh(ctx, project)
+			}
+		}
+	}()
+}
+
+// Enqueue schedules a refresh of a project. It reports false when a refresh of
+// the project was already waiting, in which case the request is merged into it.
+func (r *ProjectRefresher) Enqueue(pathWithNamespace string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.queued[pathWithNamespace] {
+		return false
+	}
+	r.queued[pathWithNamespace] = true
+	r.pending = append(r.pending, pathWithNamespace)
+
+	select {
+	case r.wake <- struct{}{}:
+	default: // The worker is already awake
+	}
+	return true
+}
+
+// next removes the oldest pending project from the queue. A request arriving
+// while it is being refreshed queues it again, since the refresh may have read
+// the project before the change that triggered the request.
+func (r *ProjectRefresher) next() (string, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.pending) == 0 {
+		return "", false
+	}
+	project := r.pending[0]
+	r.pending = r.pending[1:]
+	delete(r.queued, project)
+	return project, true
+}
+
+func (r *ProjectRefresher) refresh(ctx context.Context, pathWithNamespace string) {
+	ctx, cancel := context.WithTimeout(ctx, projectRefreshTimeout)
+	defer cancel()
+	log.Printf("INFO: Refreshing project %s.", pathWithNamespace)
+	if _, err := r.discovery.RefreshProject(ctx, pathWithNamespace); err != nil {
+		log.Printf("ERROR: Project refresh failed: %v", err)
+	}
+}
@@ -0,0 +1,258 @@
+package application
+
+import (
+	"crypto/sha256"
+	"dev-compass/internal/domain/entities"
+	"dev-compass/internal/domain/ports"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+)
+
+// DiscoveryReport summarizes the changes applied to the catalog by a discovery run.
+type DiscoveryReport struct {
+	Created   int           `json:"created"`
+	Updated   int           `json:"updated"`
+	Unchanged int           `json:"unchanged"`
+	Deleted   int           `json:"deleted"`
+	Failed    int           `json:"failed"` // Sources that could not be read and were left untouched
+	Errors    []SourceError `json:"errors,omitempty"`
+}
+
+// SourceError describes why a single source could not be read during discovery.
+type SourceError struct {
+	Source string `json:"source"`
+	Error  string `json:"error"`
+}
+
+// CatalogLock serializes the reconciliations of the catalog. Each of them ends by
+// rewriting owner warnings and relations across the whole catalog, so discovery
+// runs, project refreshes, registered locations and manual entities must not
+// overlap. A single lock is shared by every service that reconciles.
+type CatalogLock struct {
+	sync.Mutex
+}
+
+// NewCatalogLock creates a new CatalogLock.
+func NewCatalogLock() *CatalogLock {
+	return &CatalogLock{}
+}
+
+// reconciler applies discovered entities to the repository without wiping it first.
+//
+// Every entity records the source that produced it. A source is either scanned
+// successfully (its entities become the new truth for that source), failed (its
+// previously stored entities are left untouched), or missing from a complete
+// listing (its entities are removed). An entity belongs to the source that
+// stored it: another source declaring the same ref only takes it over once the
+// owning source is removed. It is safe for concurrent use.
+type reconciler struct {
+	repo      ports.EntityRepository
+	relations ports.RelationRepository
+	reject    bool // Leave out entities that don't match their schema
+	mu        sync.Mutex
+	existing  map[string]entities.Entity
+	produced  map[string]bool // Refs declared by the source that owns them
+	scanned   map[string]bool
+	failed    map[string]bool
+	complete  map[string]bool
+	contested []contestedEntity
+	report    DiscoveryReport
+}
+
+// contestedEntity is an entity declared by a source while another source owns its ref.
+type contestedEntity struct {
+	source string
+	entity *entities.Entity
+}
+
+// newReconciler loads the current catalog so discovered entities can be compared against it.
+// validationMode is the CATALOG_VALIDATION_MODE applied to entities that don't match their schema.
+func newReconciler(repo ports.EntityRepository, relations ports.RelationRepository, validationMode string) (*reconciler, error) {
+	current, err := repo.FindAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load existing entities: %w", err)
+	}
+	existing := make(map[string]entities.Entity, len(current))
+	for _, e := range current {
+		existing[e.Ref] = e
+	}
+	return &reconciler{
+		repo:      repo,
+		relations: relations,
+		reject:    validationMode == validationReject,
+		existing:  existing,
+		produced:  make(map[string]bool),
+		scanned:   make(map[string]bool),
+		failed:    make(map[string]bool),
+		complete:  make(map[string]bool),
+	}, nil
+}
+
+// apply records the full set of entities currently produced by a source,
+// saving only the ones that are new or have changed.
+func (r *reconciler) apply(source string, discovered []*entities.Entity) {
+	r.mu.Lock()
+	r.scanned[source] = true
+	r.mu.Unlock()
+
+	for _, entity := range discovered {
+		entity.Source = source
+		entity.Ref = entity.CanonicalRef()
+		if r.reject {
+			// Failing the source keeps the last valid version of the entity in the catalog.
+			if violations := schemaViolations(entity); violations != "" {
+				r.fail(source, fmt.Errorf("entity %s does not match its schema: %s", entity.Ref, violations))
+				continue
+			}
+		}
+		hash, err := entityHash(entity)
+		if err != nil {
+			r.fail(source, fmt.Errorf("failed to hash entity %s: %w", entity.Ref, err))
+			continue
+		}
+		entity.Hash = hash
+
+		r.mu.Lock()
+		previous, found := r.existing[entity.Ref]
+		if found && previous.Source != "" && previous.Source != source {
+			// Whether the owner is removed is only known once every source was applied.
+			r.contested = append(r.contested, contestedEntity{source: source, entity: entity})
+			r.mu.Unlock()
+			continue
+		}
+		r.produced[entity.Ref] = true
+		if found && previous.Hash == hash {
+			r.report.Unchanged++
+			r.mu.Unlock()
+			continue
+		}
+		r.mu.Unlock()
+
+		var stamped *entities.Entity
+		if found {
+			stamped = &previous
+		}
+		if err := r.save(entity, stamped); err != nil {
+			r.fail(source, err)
+			continue
+		}
+
+		r.mu.Lock()
+		// Later sources declaring the same ref must see this one as its owner.
+		r.existing[entity.Ref] = *entity
+		if found {
+			r.report.Updated++
+			log.Printf("INFO: Updated entity: %s from %s", entity.Ref, source)
+		} else {
+			r.report.Created++
+			log.Printf("INFO: Created entity: %s from %s", entity.Ref, source)
+		}
+		r.mu.Unlock()
+	}
+}
+
+// save stamps an entity against its stored version, if any, and saves it.
+func (r *reconciler) save(entity *entities.Entity, previous *entities.Entity) error {
+	stampEntity(entity, previous)
+	if err := r.repo.Save(entity); err != nil {
+		return fmt.Errorf("failed to save entity %s to database: %w", entity.Ref, err)
+	}
+	return nil
+}
+
+// fail marks a source as unreadable, so none of its stored entities are removed.
+func (r *reconciler) fail(source string, err error) {
+	log.Printf("ERROR: %s: %v", source, err)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.failed[source] = true
+	r.report.Errors = append(r.report.Errors, SourceError{Source: source, Error: err.Error()})
+}
+
+// markComplete declares that every source with the given prefix was listed,
+// so stored entities from sources that were not seen can be removed safely.
+func (r *reconciler) markComplete(prefix string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.complete[prefix] = true
+}
+
+// finish removes entities whose source disappeared or stopped declaring them,
+// hands contested entities over when their owner was removed, refreshes owner
+// warnings and relations and returns the summary of the run.
+func (r *reconciler) finish() DiscoveryReport {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	deleted := make(map[string]bool)
+	for ref, entity := range r.existing {
+		if entity.Source == "" || r.produced[ref] || r.failed[entity.Source] {
+			continue
+		}
+		if !r.scanned[entity.Source] && !r.complete[sourcePrefix(entity.Source)] {
+			continue
+		}
+		if err := r.repo.Delete(ref); err != nil {
+			log.Printf("ERROR: Failed to delete stale entity %s: %v", ref, err)
+			continue
+		}
+		deleted[ref] = true
+		r.report.Deleted++
+		log.Printf("INFO: Deleted entity: %s, no longer provided by %s", ref, entity.Source)
+	}
+
+	for _, contested := range r.contested {
+		ref, owner := contested.entity.Ref, r.existing[contested.entity.Ref].Source
+		if !deleted[ref] {
+			log.Printf("WARN: %s: entity %s is already provided by %s, skipping it.", contested.source, ref, owner)
+			r.report.Errors = append(r.report.Errors, SourceError{
+				Source: contested.source,
+				Error:  fmt.Sprintf("entity %s is already provided by %s", ref, owner),
+			})
+			continue
+		}
+		// The first source to declare it takes the ref over; any other one still conflicts.
+		deleted[ref] = false
+		if err := r.save(contested.entity, nil); err != nil {
+			log.Printf("ERROR: %s: %v", contested.source, err)
+			r.report.Errors = append(r.report.Errors, SourceError{Source: contested.source, Error: err.Error()})
+			continue
+		}
+		r.existing[ref] = *contested.entity
+		r.report.Created++
+		log.Printf("INFO: Created entity: %s from %s, taking it over from %s", ref, contested.source, owner)
+	}
+
+	// Owners can only be checked once the whole catalog is up to date.
+	if err := resolveOwners(r.repo); err != nil {
+		log.Printf("ERROR: Failed to resolve entity owners: %v", err)
+	}
+	if err := refreshRelations(r.repo, r.relations); err != nil {
+		log.Printf("ERROR: Failed to refresh entity relations: %v", err)
+	}
+	r.report.Failed = len(r.failed)
+	return r.report
+}
+
+// sourcePrefix returns the provider part of a source identifier, e.g. "gitlab:" for "gitlab:group/project".
+func sourcePrefix(source string) string {
+	if i := strings.Index(source, ":"); i >= 0 {
+		return source[:i+1]
+	}
+	return source
+}
+
+// entityHash returns a stable digest of everything discovery writes for an entity.
+func entityHash(entity *entities.Entity) (string, error) {
+	data, err := json.Marshal(entity)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,235 @@
+package application
+
+import (
+	"dev-compass/internal/domain/entities"
+	"dev-compass/internal/infrastructure/persistence/inmemory"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+var errTest = errors.New("unreachable")
+
+// newTestReconciler returns a reconciler over an in-memory catalog holding stored.
+func newTestReconciler(t *testing.T, stored ...*entities.Entity) (*reconciler, *inmemory.EntityRepository) {
+	t.Helper()
+	repo, err := inmemory.NewEntityRepository("", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entity := range stored {
+		if err := repo.Save(entity); err != nil {
+			t.Fatal(err)
+		}
+	}
+	rec, err := newReconciler(repo, inmemory.NewRelationRepository(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return rec, repo
+}
+
+// testEntity returns a Component with the given name, description and source.
+func testEntity(name, description, source string) *entities.Entity {
+	spec, _ := json.Marshal(map[string]string{"type": "service", "lifecycle": "production"})
+	entity := &entities.Entity{
+		Kind:     "Component",
+		Metadata: entities.Metadata{Name: name, Namespace: entities.DefaultNamespace, Description: description},
+		Spec:     spec,
+		Source:   source,
+	}
+	entity.Ref = entity.CanonicalRef()
+	return entity
+}
+
+// storedSource returns the source of the stored entity with the given name, or "" when it does not exist.
+func storedSource(t *testing.T, repo *inmemory.EntityRepository, name string) string {
+	t.Helper()
+	entity, err := repo.FindByRef(entities.EntityRef("Component", entities.DefaultNamespace, name))
+	if err != nil {
+		return ""
+	}
+	return entity.Source
+}
+
+func TestReconcilerRefConflicts(t *testing.T) {
+	tests := []struct {
+		name       string
+		stored     []*entities.Entity
+		run        func(rec *reconciler)
+		wantSource string
+		wantErrors int
+	}{
+		{
+			name:   "another active source keeps the entity",
+			stored: []*entities.Entity{testEntity("a", "from gitlab", "gitlab:group/a")},
+			run: func(rec *reconciler) {
+				rec.apply("location:1", []*entities.Entity{testEntity("a", "from a location", "")})
+			},
+			wantSource: "gitlab:group/a",
+			wantErrors: 1,
+		},
+		{
+			name:   "the owner declaring it again keeps it",
+			stored: []*entities.Entity{testEntity("a", "from gitlab", "gitlab:group/a")},
+			run: func(rec *reconciler) {
+				rec.apply("location:1", []*entities.Entity{testEntity("a", "from a location", "")})
+				rec.apply("gitlab:group/a", []*entities.Entity{testEntity("a", "from gitlab", "")})
+			},
+			wantSource: "gitlab:group/a",
+			wantErrors: 1,
+		},
+		{
+			name:   "a failed owner keeps it",
+			stored: []*entities.Entity{testEntity("a", "from gitlab", "gitlab:group/a")},
+			run: func(rec *reconciler) {
+				rec.apply("location:1", []*entities.Entity{testEntity("a", "from a location", "")})
+				rec.fail("gitlab:group/a", errTest)
+				rec.markComplete("gitlab:")
+			},
+			wantSource: "gitlab:group/a",
+			wantErrors: 2,
+		},
+		{
+			name:   "a removed owner hands it over",
+			stored: []*entities.Entity{testEntity("a", "from gitlab", "gitlab:group/a")},
+			run: func(rec *reconciler) {
+				rec.apply("location:1", []*entities.Entity{testEntity("a", "from a location", "")})
+				rec.markComplete("gitlab:")
+			},
+			wantSource: "location:1",
+		},
+		{
+			name: "the first of two new sources owns it",
+			run: func(rec *reconciler) {
+				rec.apply("location:1", []*entities.Entity{testEntity("a", "first", "")})
+				rec.apply("location:2", []*entities.Entity{testEntity("a", "second", "")})
+			},
+			wantSource: "location:1",
+			wantErrors: 1,
+		},
+		{
+			name:   "entities without a source are taken over",
+			stored: []*entities.Entity{testEntity("a", "legacy", "")},
+			run: func(rec *reconciler) {
+				rec.apply("location:1", []*entities.Entity{testEntity("a", "from a location", "")})
+			},
+			wantSource: "location:1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec, repo := newTestReconciler(t, tt.stored...)
+			tt.run(rec)
+			report := rec.finish()
+
+			if got := storedSource(t, repo, "a"); got != tt.wantSource {
+				t.Errorf("entity source = %q, want %q", got, tt.wantSource)
+			}
+			if len(report.Errors) != tt.wantErrors {
+				t.Errorf("got %d errors, want %d: %+v", len(report.Errors), tt.wantErrors, report.Errors)
+			}
+		})
+	}
+}
+
+// hashedEntity sets the hash discovery computes for an entity, as it is stored
+// after a discovery run.
+func hashedEntity(t *testing.T, entity *entities.Entity) *entities.Entity {
+	t.Helper()
+	hash, err := entityHash(entity)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entity.Hash = hash
+	return entity
+}
+
+func TestReconcilerDeletionRules(t *testing.T) {
+	tests := []struct {
+		name       string
+		stored     *entities.Entity
+		run        func(rec *reconciler)
+		wantKept   bool
+		wantReport DiscoveryReport
+	}{
+		{
+			name:   "a scanned source declaring it again keeps it",
+			stored: hashedEntity(t, testEntity("a", "v1", "gitlab:group/a")),
+			run: func(rec *reconciler) {
+				rec.apply("gitlab:group/a", []*entities.Entity{testEntity("a", "v1", "")})
+			},
+			wantKept:   true,
+			wantReport: DiscoveryReport{Unchanged: 1},
+		},
+		{
+			name:   "a scanned source changing it updates it",
+			stored: testEntity("a", "v1", "gitlab:group/a"),
+			run: func(rec *reconciler) {
+				rec.apply("gitlab:group/a", []*entities.Entity{testEntity("a", "v2", "")})
+			},
+			wantKept:   true,
+			wantReport: DiscoveryReport{Updated: 1},
+		},
+		{
+			name:   "a scanned source no longer declaring it deletes it",
+			stored: testEntity("a", "v1", "gitlab:group/a"),
+			run: func(rec *reconciler) {
+				rec.apply("gitlab:group/a", nil)
+			},
+			wantReport: DiscoveryReport{Deleted: 1},
+		},
+		{
+			name:   "a failed source keeps it",
+			stored: testEntity("a", "v1", "gitlab:group/a"),
+			run: func(rec *reconciler) {
+				rec.apply("gitlab:group/a", nil)
+				rec.fail("gitlab:group/a", errTest)
+			},
+			wantKept:   true,
+			wantReport: DiscoveryReport{Failed: 1},
+		},
+		{
+			name:   "a source missing from a complete listing deletes it",
+			stored: testEntity("a", "v1", "gitlab:group/a"),
+			run: func(rec *reconciler) {
+				rec.markComplete("gitlab:")
+			},
+			wantReport: DiscoveryReport{Deleted: 1},
+		},
+		{
+			name:   "a source that was not listed keeps it",
+			stored: testEntity("a", "v1", "gitlab:group/a"),
+			run: func(rec *reconciler) {
+				rec.markComplete("github:")
+			},
+			wantKept: true,
+		},
+		{
+			name:   "an entity without a source is kept",
+			stored: testEntity("a", "legacy", ""),
+			run: func(rec *reconciler) {
+				rec.markComplete("gitlab:")
+				rec.markComplete("")
+			},
+			wantKept: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec, repo := newTestReconciler(t, tt.stored)
+			tt.run(rec)
+			report := rec.finish()
+
+			_, err := repo.FindByRef(tt.stored.Ref)
+			if kept := err == nil; kept != tt.wantKept {
+				t.Errorf("entity kept = %v, want %v", kept, tt.wantKept)
+			}
+			report.Errors = nil
+			if !reflect.DeepEqual(report, tt.wantReport) {
+				t.Errorf("report = %+v, want %+v", report, tt.wantReport)
+			}
+		})
+	}
+}
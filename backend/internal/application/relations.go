@@ -0,0 +1,132 @@
+package application
+
+import (
+	"dev-compass/internal/domain/entities"
+	"dev-compass/internal/domain/ports"
+	"encoding/json"
+	"fmt"
+	"log"
+	"reflect"
+	"sort"
+)
+
+// entityRelations returns every relation an entity declares, along with the
+// inverse of each one. They come from spec.relations (shorthand fields included),
+// spec.owner, spec.system and, for systems, spec.domain.
+func entityRelations(entity entities.Entity) []entities.EntityRelation {
+	var spec struct {
+		Owner     string          `json:"owner"`
+		System    string          `json:"system"`
+		Domain    string          `json:"domain"`
+		Relations json.RawMessage `json:"relations"`
+	}
+	if err := json.Unmarshal(entity.Spec, &spec); err != nil {
+		log.Printf("ERROR: could not unmarshal spec for entity %s: %v", entity.Ref, err)
+		return nil
+	}
+
+	var declared []entities.Relation
+	if len(spec.Relations) > 0 && string(spec.Relations) != "null" {
+		if err := json.Unmarshal(spec.Relations, &declared); err != nil {
+			log.Printf("WARN: could not unmarshal relations for %s: %v", entity.Ref, err)
+		}
+	}
+
+	type edge struct{ relationType, target string }
+	var edges []edge
+	for _, relation := range declared {
+		if relation.Type == "" || relation.Target.Name == "" {
+			continue
+		}
+		edges = append(edges, edge{relation.Type, relation.Target.Ref()})
+	}
+	if spec.Owner != "" {
+		edges = append(edges, edge{entities.RelationOwnedBy, groupRef(spec.Owner)})
+	}
+	if spec.System != "" && entity.Kind != "System" {
+		edges = append(edges, edge{entities.RelationPartOf, refWithDefaultKind(spec.System, "System")})
+	}
+	if spec.Domain != "" && entity.Kind == "System" {
+		edges = append(edges, edge{entities.RelationPartOf, refWithDefaultKind(spec.Domain, "Domain")})
+	}
+
+	seen := make(map[entities.EntityRelation]bool)
+	var relations []entities.EntityRelation
+	add := func(relation entities.EntityRelation) {
+		if !seen[relation] {
+			seen[relation] = true
+			relations = append(relations, relation)
+		}
+	}
+	for _, e := range edges {
+		add(entities.EntityRelation{SourceRef: entity.Ref, Type: e.relationType, TargetRef: e.target, OriginRef: entity.Ref})
+		if inverse, ok := entities.InverseRelation(e.relationType); ok {
+			add(entities.EntityRelation{SourceRef: e.target, Type: inverse, TargetRef: entity.Ref, OriginRef: entity.Ref})
+		}
+	}
+	sortRelations(relations)
+	return relations
+}
+
+// refreshRelations brings the relation table in line with the catalog, rewriting
+// only the relations of entities whose declarations changed or that were removed.
+func refreshRelations(repo ports.EntityRepository, relations ports.RelationRepository) error {
+	allEntities, err := repo.FindAll()
+	if err != nil {
+		return fmt.Errorf("failed to load entities: %w", err)
+	}
+	stored, err := relations.FindAll()
+	if err != nil {
+		return fmt.Errorf("failed to load relations: %w", err)
+	}
+
+	current := make(map[string][]entities.EntityRelation)
+	for _, relation := range stored {
+		current[relation.OriginRef] = append(current[relation.OriginRef], relation)
+	}
+
+	for _, entity := range allEntities {
+		declared := entityRelations(entity)
+		existing := current[entity.Ref]
+		delete(current, entity.Ref)
+
+		sortRelations(existing)
+		if len(declared) == 0 && len(existing) == 0 || reflect.DeepEqual(declared, existing) {
+			continue
+		}
+		if err := relations.ReplaceForOrigin(entity.Ref, declared); err != nil {
+			log.Printf("ERROR: Failed to save relations of entity %s: %v", entity.Ref, err)
+		}
+	}
+
+	// Whatever is left was declared by entities that no longer exist.
+	for origin := range current {
+		if err := relations.ReplaceForOrigin(origin, nil); err != nil {
+			log.Printf("ERROR: Failed to delete relations of entity %s: %v", origin, err)
+		}
+	}
+	return nil
+}
+
+// sortRelations orders relations by source, type and target.
+func sortRelations(relations []entities.EntityRelation) {
+	sort.Slice(relations, func(i, j int) bool {
+		a, b := relations[i], relations[j]
+		if a.SourceRef != b.SourceRef {
+			return a.SourceRef < b.SourceRef
+		}
+		if a.Type != b.Type {
+			return a.Type < b.Type
+		}
+		return a.TargetRef < b.TargetRef
+	})
+}
+
+// refWithDefaultKind returns the canonical reference of an entity reference, using kind when it has none.
+func refWithDefaultKind(ref, kind string) string {
+	target := entities.ParseEntityRef(ref)
+	if target.Kind == "" {
+		target.Kind = kind
+	}
+	return target.Ref()
+}
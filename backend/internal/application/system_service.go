@@ -0,0 +1,165 @@
+package application
+
+import (
+	"dev-compass/internal/domain/entities"
+	"dev-compass/internal/domain/ports"
+	"encoding/json"
+	"errors"
+	"log"
+	"sort"
+	"time"
+)
+
+// SystemMember is a component, API or resource that is part of a system.
+type SystemMember struct {
+	Ref               string                `json:"ref"`
+	Name              string                `json:"name"`
+	Type              string                `json:"type,omitempty"`
+	Lifecycle         string                `json:"lifecycle,omitempty"`
+	Owner             string                `json:"owner,omitempty"`
+	LastRunStatus     string                `json:"lastRunStatus,omitempty"`
+	LatestDeployments []ComponentDeployment `json:"latestDeployments,omitempty"` // Most recent deployment per environment
+}
+
+// SystemView aggregates a system with its members and their owners.
+type SystemView struct {
+	entities.Entity
+	Owners     []string       `json:"owners"`
+	Components []SystemMember `json:"components"`
+	APIs       []SystemMember `json:"apis"`
+	Resources  []SystemMember `json:"resources"`
+}
+
+// memberSpec holds the spec fields shared by the kinds that can be part of a system.
+type memberSpec struct {
+	Type        string                `json:"type"`
+	Lifecycle   string                `json:"lifecycle"`
+	Owner       string                `json:"owner"`
+	Deployments []entities.Deployment `json:"deployments"`
+	CI          entities.CISpec       `json:"ci"`
+}
+
+// SystemService provides services related to System entities.
+type SystemService struct {
+	repo      ports.EntityRepository
+	relations ports.RelationRepository
+}
+
+// NewSystemService creates a new SystemService.
+func NewSystemService(repo ports.EntityRepository, relations ports.RelationRepository) *SystemService {
+	return &SystemService{repo: repo, relations: relations}
+}
+
+// GetSystem returns a system with its components, APIs and resources. Entities
+// belong to a system through a partOf relation, which spec.system declares too.
+func (s *SystemService) GetSystem(namespace, name string) (*SystemView, error) {
+	system, err := s.repo.FindByRef(entities.EntityRef("System", namespace, name))
+	if err != nil {
+		return nil, err
+	}
+
+	relations, err := s.relations.FindByTarget(system.Ref)
+	if err != nil {
+		return nil, err
+	}
+
+	view := &SystemView{
+		Entity:     *system,
+		Owners:     []string{},
+		Components: []SystemMember{},
+		APIs:       []SystemMember{},
+		Resources:  []SystemMember{},
+	}
+	owners := make(map[string]bool)
+	var systemSpec entities.SystemSpec
+	if err := json.Unmarshal(system.Spec, &systemSpec); err == nil && systemSpec.Owner != "" {
+		owners[systemSpec.Owner] = true
+	}
+
+	seen := make(map[string]bool)
+	for _, relation := range relations {
+		if relation.Type != entities.RelationPartOf || seen[relation.SourceRef] {
+			continue
+		}
+		seen[relation.SourceRef] = true
+
+		entity, err := s.repo.FindByRef(relation.SourceRef)
+		if errors.Is(err, ports.ErrNotFound) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		if entity.Kind != "Component" && entity.Kind != "API" && entity.Kind != "Resource" {
+			continue
+		}
+		var spec memberSpec
+		if err := json.Unmarshal(entity.Spec, &spec); err != nil {
+			log.Printf("ERROR: could not unmarshal spec for entity %s: %v", entity.Ref, err)
+			continue
+		}
+
+		member := SystemMember{
+			Ref:       entity.Ref,
+			Name:      entity.Metadata.Name,
+			Type:      spec.Type,
+			Lifecycle: spec.Lifecycle,
+			Owner:     spec.Owner,
+		}
+		if spec.Owner != "" {
+			owners[spec.Owner] = true
+		}
+
+		switch entity.Kind {
+		case "Component":
+			member.LastRunStatus = spec.CI.LastRunStatus
+			member.LatestDeployments = latestDeployments(spec.Deployments)
+			view.Components = append(view.Components, member)
+		case "API":
+			view.APIs = append(view.APIs, member)
+		case "Resource":
+			view.Resources = append(view.Resources, member)
+		}
+	}
+
+	for owner := range owners {
+		view.Owners = append(view.Owners, owner)
+	}
+	sort.Strings(view.Owners)
+	for _, members := range [][]SystemMember{view.Components, view.APIs, view.Resources} {
+		sort.Slice(members, func(i, j int) bool { return members[i].Ref < members[j].Ref })
+	}
+	return view, nil
+}
+
+// latestDeployments returns the most recent deployment of each environment, ordered by environment.
+func latestDeployments(deployments []entities.Deployment) []ComponentDeployment {
+	latest := make(map[string]entities.Deployment)
+	for _, dep := range deployments {
+		current, found := latest[dep.Environment]
+		if !found || deploymentTime(dep).After(deploymentTime(current)) {
+			latest[dep.Environment] = dep
+		}
+	}
+
+	result := make([]ComponentDeployment, 0, len(latest))
+	for _, dep := range latest {
+		result = append(result, ComponentDeployment{
+			Environment: dep.Environment,
+			Version:     dep.Version,
+			Timestamp:   dep.Timestamp,
+			Entidad:     dep.Entidad,
+		})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Environment < result[j].Environment })
+	return result
+}
+
+// deploymentTime parses a deployment timestamp, written either by time.Time.String or as RFC 3339.
+func deploymentTime(dep entities.Deployment) time.Time {
+	for _, layout := range []string{"2006-01-02 15:04:05.999999999 -0700 MST", time.RFC3339} {
+		if t, err := time.Parse(layout, dep.Timestamp); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
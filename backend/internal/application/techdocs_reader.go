@@ -0,0 +1,95 @@
+package application
+
+import (
+	"context"
+	"dev-compass/internal/domain/entities"
+	"dev-compass/internal/infrastructure/config"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// ErrTechDocsUnavailable is returned when the documentation an entity points to
+// can't be located, because the entity was not read from a file or a URL.
+var ErrTechDocsUnavailable = errors.New("documentation is only served for entities read from files or URLs")
+
+// TechDocsReader reads the documentation entities point to with their techdocs-ref
+// annotation. It is read from the repository the entity was read from: from disk
+// for local repositories, or over HTTP(S) with the same tokens and host
+// restrictions as remote catalog files.
+type TechDocsReader struct {
+	urls *urlReader
+}
+
+// NewTechDocsReader creates a new TechDocsReader.
+func NewTechDocsReader(cfg *config.Config) *TechDocsReader {
+	return &TechDocsReader{urls: newURLReader(cfg)}
+}
+
+// Read returns a file of the documentation of an entity, docPath being relative
+// to the directory named by its techdocs-ref annotation. It reports false when the
+// entity has no such annotation. A missing file is reported with an error
+// matching fs.ErrNotExist.
+func (r *TechDocsReader) Read(ctx context.Context, entity *entities.Entity, docPath string) ([]byte, bool, error) {
+	ref, ok := entity.Metadata.Annotations[techDocsRefAnnotation].(string)
+	if !ok {
+		return nil, false, nil
+	}
+	dir, err := techDocsRefDir(ref)
+	if err != nil {
+		return nil, true, fmt.Errorf("%s %q of %s %v", techDocsRefAnnotation, ref, entity.Ref, err)
+	}
+	file := path.Join(dir, path.Clean("/"+docPath))
+
+	location, _ := entity.Metadata.Annotations[sourceLocationAnnotation].(string)
+	relative, _ := entity.Metadata.Annotations[sourcePathAnnotation].(string)
+	if catalogFile, local := strings.CutPrefix(location, "file:"); local {
+		root := repositoryRoot(filepath.ToSlash(catalogFile), relative)
+		data, err := os.ReadFile(filepath.Join(filepath.FromSlash(root), filepath.FromSlash(file)))
+		return data, true, err
+	}
+	if catalogURL, remote := strings.CutPrefix(location, "url:"); remote {
+		root := repositoryRoot(rawFileURL(catalogURL, relative), relative)
+		segments := strings.Split(file, "/")
+		for i, segment := range segments {
+			segments[i] = url.PathEscape(segment)
+		}
+		data, err := r.urls.read(ctx, root+"/"+strings.Join(segments, "/"))
+		return data, true, err
+	}
+	return nil, true, fmt.Errorf("%w: %s has no %s", ErrTechDocsUnavailable, entity.Ref, sourceLocationAnnotation)
+}
+
+// repositoryRoot returns the root of the repository a catalog file, a path or a
+// URL, was read from. relative is the path of the file within its repository;
+// without it, the file is at the root.
+func repositoryRoot(catalogFile, relative string) string {
+	if relative != "" {
+		if root, found := strings.CutSuffix(catalogFile, "/"+strings.TrimPrefix(relative, "/")); found {
+			return root
+		}
+	}
+	return catalogFile[:max(strings.LastIndex(catalogFile, "/"), 0)]
+}
+
+// rawFileURL returns the URL of the raw content of a catalog file from the URL of
+// the page showing it in a GitLab or GitHub repository. Only files read from a
+// repository, which have a relative path, are shown on such pages.
+func rawFileURL(catalogURL, relative string) string {
+	switch {
+	case relative == "":
+		return catalogURL
+	case strings.Contains(catalogURL, "/-/blob/"):
+		return strings.Replace(catalogURL, "/-/blob/", "/-/raw/", 1)
+	case strings.HasPrefix(catalogURL, "https://github.com/"):
+		// github.com redirects raw files to this host, which is the one the token is sent to.
+		return "https://raw.githubusercontent.com/" + strings.Replace(strings.TrimPrefix(catalogURL, "https://github.com/"), "/blob/", "/", 1)
+	default:
+		// GitHub Enterprise serves raw files next to the pages.
+		return strings.Replace(catalogURL, "/blob/", "/raw/", 1)
+	}
+}
@@ -0,0 +1,128 @@
+package application
+
+import (
+	"context"
+	"dev-compass/internal/domain/entities"
+	"dev-compass/internal/infrastructure/config"
+	"errors"
+	"gorm.io/datatypes"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"testing"
+)
+
+func TestRawFileURL(t *testing.T) {
+	tests := []struct {
+		catalogURL, relative, want string
+	}{
+		{"https://gitlab.com/acme/orders/-/blob/main/catalog-info.yaml", "catalog-info.yaml", "https://gitlab.com/acme/orders/-/raw/main/catalog-info.yaml"},
+		{"https://github.com/acme/orders/blob/main/sub/catalog-info.yaml", "sub/catalog-info.yaml", "https://raw.githubusercontent.com/acme/orders/main/sub/catalog-info.yaml"},
+		{"https://github.example.com/acme/orders/blob/main/catalog-info.yaml", "catalog-info.yaml", "https://github.example.com/acme/orders/raw/main/catalog-info.yaml"},
+		{"https://example.com/blob/catalog-info.yaml", "", "https://example.com/blob/catalog-info.yaml"},
+	}
+	for _, tt := range tests {
+		if got := rawFileURL(tt.catalogURL, tt.relative); got != tt.want {
+			t.Errorf("rawFileURL(%q, %q) = %q, want %q", tt.catalogURL, tt.relative, got, tt.want)
+		}
+	}
+}
+
+func TestTechDocsReaderRead(t *testing.T) {
+	local := t.TempDir()
+	writeFiles(t, local, map[string]string{"site/guide/index.md": "# Local"})
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.EscapedPath() {
+		case "/acme/orders/-/raw/main/site/guide/index.md", "/shared/site/guide/index.md":
+			_, _ = w.Write([]byte("# Remote " + r.URL.Path))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	host, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := &config.Config{GitLab: &config.GitLab{}, GitHub: &config.GitHub{}, Discovery: &config.Discovery{AllowedHosts: []string{host.Hostname()}}}
+	reader := NewTechDocsReader(cfg)
+
+	documented := func(kind, location, relative string) *entities.Entity {
+		annotations := datatypes.JSONMap{techDocsRefAnnotation: "dir:./site", sourceLocationAnnotation: location}
+		if relative != "" {
+			annotations[sourcePathAnnotation] = relative
+		}
+		return &entities.Entity{Kind: kind, Ref: kind + ":default/docs", Metadata: entities.Metadata{Annotations: annotations}}
+	}
+	tests := []struct {
+		name      string
+		entity    *entities.Entity
+		want      string
+		annotated bool
+		wantErr   error
+	}{
+		{
+			name:   "no annotation",
+			entity: &entities.Entity{Metadata: entities.Metadata{Annotations: datatypes.JSONMap{}}},
+		},
+		{
+			name:      "local repository",
+			entity:    documented("Component", "file:"+filepath.Join(local, "catalog-info.yaml"), ""),
+			want:      "# Local",
+			annotated: true,
+		},
+		{
+			name:      "local catalog file in a sub-directory",
+			entity:    documented("System", "file:"+filepath.Join(local, "nested", "catalog-info.yaml"), "nested/catalog-info.yaml"),
+			want:      "# Local",
+			annotated: true,
+		},
+		{
+			name:      "GitLab repository",
+			entity:    documented("Component", "url:"+server.URL+"/acme/orders/-/blob/main/deploy/catalog-info.yaml", "deploy/catalog-info.yaml"),
+			want:      "# Remote /acme/orders/-/raw/main/site/guide/index.md",
+			annotated: true,
+		},
+		{
+			name:      "registered URL",
+			entity:    documented("Domain", "url:"+server.URL+"/shared/catalog-info.yaml", ""),
+			want:      "# Remote /shared/site/guide/index.md",
+			annotated: true,
+		},
+		{
+			name:      "missing remote file",
+			entity:    documented("Component", "url:"+server.URL+"/elsewhere/catalog-info.yaml", ""),
+			annotated: true,
+			wantErr:   fs.ErrNotExist,
+		},
+		{
+			name:      "unknown source",
+			entity:    documented("Component", "", ""),
+			annotated: true,
+			wantErr:   ErrTechDocsUnavailable,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content, annotated, err := reader.Read(context.Background(), tt.entity, "/guide/index.md")
+			if annotated != tt.annotated {
+				t.Errorf("annotated = %v, want %v", annotated, tt.annotated)
+			}
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("error = %v", err)
+			}
+			if string(content) != tt.want {
+				t.Errorf("content = %q, want %q", content, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,37 @@
+package application
+
+import (
+	"context"
+	"sync"
+)
+
+// forEachConcurrently calls fn for every item using at most workers goroutines.
+// Items that were not started when ctx is cancelled are skipped.
+func forEachConcurrently[T any](ctx context.Context, workers int, items []T, fn func(T)) {
+	if workers < 1 {
+		workers = 1
+	}
+
+	jobs := make(chan T)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for item := range jobs {
+				fn(item)
+			}
+		}()
+	}
+
+feed:
+	for _, item := range items {
+		select {
+		case <-ctx.Done():
+			break feed
+		case jobs <- item:
+		}
+	}
+	close(jobs)
+	wg.Wait()
+}
@@ -4,15 +4,23 @@ import "gorm.io/datatypes"
 
 // Entity represents a catalog entity, which can be a Component, Resource, etc.
 type Entity struct {
+	Ref        string         `json:"-" gorm:"primaryKey"` // Canonical "kind:namespace/name" reference, see CanonicalRef
 	APIVersion string         `json:"apiVersion" gorm:"-"`
 	Kind       string         `json:"kind" gorm:"index"` // Now stored in DB
 	Metadata   Metadata       `json:"metadata" gorm:"embedded;embeddedPrefix:metadata_"`
-	Spec       datatypes.JSON `json:"spec" gorm:"type:jsonb"` // Generic spec
+	Spec       datatypes.JSON `json:"spec" gorm:"type:jsonb"`        // Generic spec
+	Source     string         `json:"source,omitempty" gorm:"index"` // Discovery source that produced the entity, e.g. "gitlab:group/project"
+	Status     *EntityStatus  `json:"status,omitempty" gorm:"serializer:json;type:jsonb"`
+	Hash       string         `json:"-"` // Content hash used to skip unchanged entities on rediscovery
 }
 
 // Metadata contains the metadata for a component.
 type Metadata struct {
-	Name        string                    `json:"name" gorm:"primaryKey"`
+	UID         string                    `json:"uid,omitempty" gorm:"uniqueIndex"` // Assigned on first save, kept across rediscoveries
+	Generation  int64                     `json:"generation,omitempty"`             // Bumped every time the spec changes
+	ETag        string                    `json:"etag,omitempty"`                   // Changes on every write, used for If-Match checks
+	Name        string                    `json:"name" gorm:"index"`
+	Namespace   string                    `json:"namespace" gorm:"index;default:default"`
 	Description string                    `json:"description,omitempty"`
 	Tags        datatypes.JSON            `json:"tags,omitempty" gorm:"type:jsonb"`
 	Labels      datatypes.JSONMap         `json:"labels,omitempty" gorm:"type:jsonb"`
@@ -50,10 +58,31 @@ type ComponentSpec struct {
 
 // ResourceSpec defines the specification of a resource.
 type ResourceSpec struct {
-	Type  string `json:"type" yaml:"type"`
+	Type   string `json:"type" yaml:"type"`
+	Owner  string `json:"owner" yaml:"owner"`
+	System string `json:"system,omitempty" yaml:"system,omitempty"`
+}
+
+// SystemSpec defines the specification of a system, a group of components, APIs and resources.
+type SystemSpec struct {
+	Owner  string `json:"owner" yaml:"owner"`
+	Domain string `json:"domain,omitempty" yaml:"domain,omitempty"`
+}
+
+// DomainSpec defines the specification of a domain, a business area grouping systems.
+type DomainSpec struct {
 	Owner string `json:"owner" yaml:"owner"`
 }
 
+// APISpec defines the specification of an API.
+type APISpec struct {
+	Type       string `json:"type" yaml:"type"` // openapi, asyncapi, grpc or graphql
+	Lifecycle  string `json:"lifecycle" yaml:"lifecycle"`
+	Owner      string `json:"owner" yaml:"owner"`
+	System     string `json:"system,omitempty" yaml:"system,omitempty"`
+	Definition string `json:"definition" yaml:"definition"` // Fetched during discovery when it points to a file
+}
+
 // RepositorySpec defines repository-related information.
 type RepositorySpec struct {
 	Tags datatypes.JSON `json:"tags" gorm:"type:jsonb"`
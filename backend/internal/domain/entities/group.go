@@ -0,0 +1,23 @@
+package entities
+
+// GroupSpec defines the specification of a group, such as a team or a department.
+type GroupSpec struct {
+	Type     string   `json:"type" yaml:"type"`
+	Profile  Profile  `json:"profile,omitempty" yaml:"profile,omitempty"`
+	Parent   string   `json:"parent,omitempty" yaml:"parent,omitempty"`
+	Children []string `json:"children" yaml:"children"`
+	Members  []string `json:"members,omitempty" yaml:"members,omitempty"`
+}
+
+// UserSpec defines the specification of a user.
+type UserSpec struct {
+	Profile  Profile  `json:"profile,omitempty" yaml:"profile,omitempty"`
+	MemberOf []string `json:"memberOf" yaml:"memberOf"`
+}
+
+// Profile holds the display information of a group or user.
+type Profile struct {
+	DisplayName string `json:"displayName,omitempty" yaml:"displayName,omitempty"`
+	Email       string `json:"email,omitempty" yaml:"email,omitempty"`
+	Picture     string `json:"picture,omitempty" yaml:"picture,omitempty"`
+}
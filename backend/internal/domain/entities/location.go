@@ -0,0 +1,19 @@
+package entities
+
+import "time"
+
+// Location is a catalog file registered by hand through the API. It is read
+// again on every discovery run, together with the Locations it references.
+type Location struct {
+	ID        string    `json:"id" gorm:"primaryKey"`
+	Target    string    `json:"target" gorm:"uniqueIndex"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// LocationSpec defines the specification of a Location entity found in a catalog file.
+type LocationSpec struct {
+	Type     string   `json:"type,omitempty" yaml:"type,omitempty"`
+	Target   string   `json:"target,omitempty" yaml:"target,omitempty"`
+	Targets  []string `json:"targets,omitempty" yaml:"targets,omitempty"`
+	Presence string   `json:"presence,omitempty" yaml:"presence,omitempty"`
+}
@@ -0,0 +1,48 @@
+package entities
+
+import "strings"
+
+// DefaultNamespace is the namespace of entities that don't declare one.
+const DefaultNamespace = "default"
+
+// ParseEntityRef parses a Backstage entity reference string.
+// Format: [<kind>:][<namespace>/]<name>. A missing kind is left empty and a
+// missing namespace is set to DefaultNamespace.
+func ParseEntityRef(ref string) RelationTarget {
+	target := RelationTarget{Namespace: DefaultNamespace}
+	parts := strings.SplitN(ref, ":", 2)
+	var rest string
+	if len(parts) == 2 {
+		target.Kind = parts[0]
+		rest = parts[1]
+	} else {
+		rest = parts[0]
+	}
+	parts = strings.SplitN(rest, "/", 2)
+	if len(parts) == 2 {
+		target.Namespace = parts[0]
+		target.Name = parts[1]
+	} else {
+		target.Name = parts[0]
+	}
+	return target
+}
+
+// EntityRef returns the canonical reference of an entity, "kind:namespace/name".
+// References are compared case-insensitively, so the result is lower case.
+func EntityRef(kind, namespace, name string) string {
+	if namespace == "" {
+		namespace = DefaultNamespace
+	}
+	return strings.ToLower(kind + ":" + namespace + "/" + name)
+}
+
+// CanonicalRef returns the canonical reference of the entity, used as its storage key.
+func (e *Entity) CanonicalRef() string {
+	return EntityRef(e.Kind, e.Metadata.Namespace, e.Metadata.Name)
+}
+
+// Ref returns the canonical reference of the relation target.
+func (t RelationTarget) Ref() string {
+	return EntityRef(t.Kind, t.Namespace, t.Name)
+}
@@ -0,0 +1,45 @@
+package entities
+
+// Relation types that are stored in both directions.
+const (
+	RelationDependsOn     = "dependsOn"
+	RelationDependencyOf  = "dependencyOf"
+	RelationProvidesAPI   = "providesApi"
+	RelationAPIProvidedBy = "apiProvidedBy"
+	RelationConsumesAPI   = "consumesApi"
+	RelationAPIConsumedBy = "apiConsumedBy"
+	RelationPartOf        = "partOf"
+	RelationHasPart       = "hasPart"
+	RelationOwnedBy       = "ownedBy"
+	RelationOwnerOf       = "ownerOf"
+)
+
+// inverseRelations maps every relation type to the type of the same relation seen from its target.
+var inverseRelations = map[string]string{
+	RelationDependsOn:     RelationDependencyOf,
+	RelationDependencyOf:  RelationDependsOn,
+	RelationProvidesAPI:   RelationAPIProvidedBy,
+	RelationAPIProvidedBy: RelationProvidesAPI,
+	RelationConsumesAPI:   RelationAPIConsumedBy,
+	RelationAPIConsumedBy: RelationConsumesAPI,
+	RelationPartOf:        RelationHasPart,
+	RelationHasPart:       RelationPartOf,
+	RelationOwnedBy:       RelationOwnerOf,
+	RelationOwnerOf:       RelationOwnedBy,
+}
+
+// InverseRelation returns the inverse of a relation type, if it has one.
+func InverseRelation(relationType string) (string, bool) {
+	inverse, ok := inverseRelations[relationType]
+	return inverse, ok
+}
+
+// EntityRelation is a directed relation between two entities, such as
+// "component:default/a dependsOn resource:default/db". Relations with a known
+// inverse are stored in both directions, so they can be queried from either end.
+type EntityRelation struct {
+	SourceRef string `json:"source" gorm:"primaryKey"`
+	Type      string `json:"type" gorm:"primaryKey"`
+	TargetRef string `json:"target" gorm:"primaryKey;index"`
+	OriginRef string `json:"origin" gorm:"primaryKey;index"` // Entity whose spec declared the relation
+}
@@ -0,0 +1,56 @@
+package entities
+
+import (
+	"encoding/json"
+	"gorm.io/datatypes"
+	"reflect"
+	"time"
+)
+
+// EntityRevision is a stored version of an entity. A revision is recorded every
+// time an entity is saved with a different content, and kept after the entity is
+// deleted, so its history can still be looked up. Repositories may prune the
+// oldest revisions of an entity to keep a bounded number of them.
+type EntityRevision struct {
+	Ref        string `json:"-" gorm:"primaryKey"`
+	Revision   int64  `json:"revision" gorm:"primaryKey;autoIncrement:false"` // Numbered from 1 for every entity
+	UID        string `json:"uid,omitempty"`
+	Generation int64  `json:"generation,omitempty"`
+	Source     string `json:"source,omitempty"`
+	// Entity is the entity as returned by the API, without its status and etag,
+	// which are derived from other entities and change without the entity being
+	// written, nor its apiVersion, which is not stored.
+	Entity    datatypes.JSON `json:"entity,omitempty" gorm:"type:jsonb"`
+	CreatedAt time.Time      `json:"createdAt"`
+}
+
+// NewEntityRevision returns the given revision of an entity, with a snapshot of its content.
+func NewEntityRevision(entity *Entity, revision int64) (*EntityRevision, error) {
+	content := *entity
+	content.APIVersion = ""
+	content.Status = nil
+	content.Metadata.ETag = ""
+	snapshot, err := json.Marshal(content)
+	if err != nil {
+		return nil, err
+	}
+	return &EntityRevision{
+		Ref:        entity.Ref,
+		Revision:   revision,
+		UID:        entity.Metadata.UID,
+		Generation: entity.Metadata.Generation,
+		Source:     entity.Source,
+		Entity:     snapshot,
+		CreatedAt:  time.Now(),
+	}, nil
+}
+
+// SameContent reports whether two revisions hold the same snapshot, whatever the
+// formatting of their JSON.
+func (r *EntityRevision) SameContent(other *EntityRevision) bool {
+	var left, right interface{}
+	if json.Unmarshal(r.Entity, &left) != nil || json.Unmarshal(other.Entity, &right) != nil {
+		return string(r.Entity) == string(other.Entity)
+	}
+	return reflect.DeepEqual(left, right)
+}
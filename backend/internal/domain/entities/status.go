@@ -0,0 +1,32 @@
+package entities
+
+// EntityStatus holds problems found with an entity after it was read, such as
+// references to entities that don't exist.
+type EntityStatus struct {
+	Items []StatusItem `json:"items"`
+}
+
+// StatusItem is a single problem reported on an entity.
+type StatusItem struct {
+	Level   string `json:"level"` // info, warning or error
+	Type    string `json:"type"`
+	Message string `json:"message"`
+}
+
+// WithItems returns a copy of the status where every item of the given type is
+// replaced by items. It returns nil when no items are left.
+func (s *EntityStatus) WithItems(itemType string, items []StatusItem) *EntityStatus {
+	var merged []StatusItem
+	if s != nil {
+		for _, item := range s.Items {
+			if item.Type != itemType {
+				merged = append(merged, item)
+			}
+		}
+	}
+	merged = append(merged, items...)
+	if len(merged) == 0 {
+		return nil
+	}
+	return &EntityStatus{Items: merged}
+}
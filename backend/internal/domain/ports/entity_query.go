@@ -0,0 +1,314 @@
+package ports
+
+import (
+	"dev-compass/internal/domain/entities"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ErrInvalidQuery is returned when an EntityQuery cannot be run, e.g. because of a
+// malformed label selector or a cursor from a query with a different sort.
+var ErrInvalidQuery = errors.New("invalid query")
+
+// Fields query results can be sorted by. Results with the same value are ordered by reference.
+const (
+	SortByName      = "name"
+	SortByNamespace = "namespace"
+	SortByKind      = "kind"
+	SortByOwner     = "owner"     // spec.owner
+	SortByLifecycle = "lifecycle" // spec.lifecycle
+	SortByType      = "type"      // spec.type
+)
+
+// EntityQuery selects a page of entities. Empty fields don't filter. Values within
+// a field are alternatives, except tags and annotations, which must all be present.
+// Kinds, owners, lifecycles and types are compared case-insensitively.
+type EntityQuery struct {
+	Search        string // Matched against the name and the description, case-insensitively
+	Kinds         []string
+	Owners        []string // spec.owner, as written in the catalog file
+	Lifecycles    []string // spec.lifecycle
+	Types         []string // spec.type
+	Tags          []string
+	LabelSelector []LabelRequirement // See ParseLabelSelector
+	Annotations   []string           // Keys of annotations that must be set
+	Sort          string             // One of the SortBy* fields, SortByName by default
+	Descending    bool
+	Limit         int    // Maximum number of entities returned, 0 for all of them
+	Cursor        string // NextCursor of the previous page
+}
+
+// EntityPage is a page of entities matching an EntityQuery.
+type EntityPage struct {
+	Entities   []entities.Entity
+	TotalCount int64  // Number of entities matching the query, across every page
+	NextCursor string // Empty on the last page
+}
+
+// SortField returns the field results are sorted by, validating it.
+func (q EntityQuery) SortField() (string, error) {
+	switch q.Sort {
+	case "":
+		return SortByName, nil
+	case SortByName, SortByNamespace, SortByKind, SortByOwner, SortByLifecycle, SortByType:
+		return q.Sort, nil
+	default:
+		return "", fmt.Errorf("%w: cannot sort by %q", ErrInvalidQuery, q.Sort)
+	}
+}
+
+// SearchResult is an entity found by a full-text search.
+type SearchResult struct {
+	Entity entities.Entity `json:"entity"`
+	Rank   float64         `json:"rank"` // Higher is more relevant
+	// Snippet is an excerpt of the description or README with the matching words
+	// wrapped in <mark> tags. The rest of the excerpt is not escaped.
+	Snippet string `json:"snippet,omitempty"`
+}
+
+// Fields facets can be computed on.
+const (
+	FacetKind      = "kind"
+	FacetNamespace = "metadata.namespace"
+	FacetTags      = "tags"
+	FacetLabel     = "metadata.labels" // Values of a label, e.g. "metadata.labels.tier"
+	FacetSpec      = "spec"            // Values of a top-level spec field, e.g. "spec.owner"
+)
+
+// Facet is a field whose distinct values are counted across the entities matching a query.
+type Facet struct {
+	Name  string // As requested, e.g. "spec.owner"
+	Field string // One of the Facet* fields
+	Key   string // The label key or spec field, for FacetLabel and FacetSpec
+}
+
+// FacetCount is a distinct value of a facet and the number of entities that have it.
+type FacetCount struct {
+	Value string `json:"value"`
+	Count int64  `json:"count"`
+}
+
+// ParseFacet reads a facet name: "kind", "metadata.namespace", "tags" (or
+// "metadata.tags"), "metadata.labels.<key>" or "spec.<field>".
+func ParseFacet(name string) (Facet, error) {
+	switch name {
+	case FacetKind, FacetNamespace, FacetTags:
+		return Facet{Name: name, Field: name}, nil
+	case "metadata.tags":
+		return Facet{Name: name, Field: FacetTags}, nil
+	}
+	if key, ok := strings.CutPrefix(name, FacetLabel+"."); ok && key != "" && !strings.Contains(key, `"`) {
+		return Facet{Name: name, Field: FacetLabel, Key: key}, nil
+	}
+	if key, ok := strings.CutPrefix(name, FacetSpec+"."); ok && key != "" && !strings.ContainsAny(key, ".'\"") {
+		return Facet{Name: name, Field: FacetSpec, Key: key}, nil
+	}
+	return Facet{}, fmt.Errorf("%w: unknown facet %q", ErrInvalidQuery, name)
+}
+
+// WithoutFilterOn returns the query without its filter on the field of a facet, so
+// the counts of a facet show every value that could be selected next.
+func (q EntityQuery) WithoutFilterOn(facet Facet) EntityQuery {
+	switch {
+	case facet.Field == FacetKind:
+		q.Kinds = nil
+	case facet.Field == FacetTags:
+		q.Tags = nil
+	case facet.Field == FacetSpec && facet.Key == "owner":
+		q.Owners = nil
+	case facet.Field == FacetSpec && facet.Key == "lifecycle":
+		q.Lifecycles = nil
+	case facet.Field == FacetSpec && facet.Key == "type":
+		q.Types = nil
+	case facet.Field == FacetLabel:
+		var selector []LabelRequirement
+		for _, requirement := range q.LabelSelector {
+			if requirement.Key != facet.Key {
+				selector = append(selector, requirement)
+			}
+		}
+		q.LabelSelector = selector
+	}
+	return q
+}
+
+// SortFacetCounts orders facet values by count, most common first, then by value.
+func SortFacetCounts(counts []FacetCount) {
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Count != counts[j].Count {
+			return counts[i].Count > counts[j].Count
+		}
+		return counts[i].Value < counts[j].Value
+	})
+}
+
+// Label selector operators, as in Kubernetes.
+const (
+	LabelEquals       = "="
+	LabelNotEquals    = "!="
+	LabelIn           = "in"
+	LabelNotIn        = "notin"
+	LabelExists       = "exists"
+	LabelDoesNotExist = "!"
+)
+
+// LabelRequirement is a single requirement of a label selector, e.g. "tier in (web,api)".
+type LabelRequirement struct {
+	Key      string
+	Operator string // One of the Label* operators
+	Values   []string
+}
+
+// Matches reports whether a set of labels satisfies the requirement. As in
+// Kubernetes, != and notin match entities without the label.
+func (r LabelRequirement) Matches(labels map[string]interface{}) bool {
+	value, found := labels[r.Key].(string)
+	switch r.Operator {
+	case LabelEquals, LabelIn:
+		return found && contains(r.Values, value)
+	case LabelNotEquals, LabelNotIn:
+		return !found || !contains(r.Values, value)
+	case LabelExists:
+		return found
+	case LabelDoesNotExist:
+		return !found
+	default:
+		return false
+	}
+}
+
+// ParseLabelSelector parses a Kubernetes-style label selector: a comma-separated
+// list of requirements, each one of "key=value", "key==value", "key!=value",
+// "key in (a,b)", "key notin (a,b)", "key" or "!key". Keys can't have double quotes,
+// which the SQLite repository can't look up.
+func ParseLabelSelector(selector string) ([]LabelRequirement, error) {
+	var requirements []LabelRequirement
+	for _, part := range splitSelector(selector) {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		requirement, err := parseLabelRequirement(part)
+		if err != nil {
+			return nil, fmt.Errorf("%w: label selector %q: %v", ErrInvalidQuery, part, err)
+		}
+		requirements = append(requirements, requirement)
+	}
+	return requirements, nil
+}
+
+func parseLabelRequirement(part string) (LabelRequirement, error) {
+	if key, ok := strings.CutPrefix(part, "!"); ok {
+		return labelRequirement(key, LabelDoesNotExist, nil)
+	}
+	if key, value, ok := strings.Cut(part, "!="); ok {
+		return labelRequirement(key, LabelNotEquals, []string{value})
+	}
+	if key, value, ok := strings.Cut(part, "=="); ok {
+		return labelRequirement(key, LabelEquals, []string{value})
+	}
+	if key, value, ok := strings.Cut(part, "="); ok {
+		return labelRequirement(key, LabelEquals, []string{value})
+	}
+
+	fields := strings.Fields(part)
+	if len(fields) == 1 {
+		return labelRequirement(fields[0], LabelExists, nil)
+	}
+	key := fields[0]
+	rest := strings.TrimSpace(strings.TrimPrefix(part, key))
+	operator := rest
+	if i := strings.IndexAny(rest, " ("); i >= 0 {
+		operator = rest[:i]
+	}
+	if operator != LabelIn && operator != LabelNotIn {
+		return LabelRequirement{}, fmt.Errorf("unknown operator %q", operator)
+	}
+	set := strings.TrimSpace(strings.TrimPrefix(rest, operator))
+	if !strings.HasPrefix(set, "(") || !strings.HasSuffix(set, ")") {
+		return LabelRequirement{}, fmt.Errorf("the values of %s must be enclosed in parentheses", operator)
+	}
+	var values []string
+	for _, value := range strings.Split(set[1:len(set)-1], ",") {
+		values = append(values, strings.TrimSpace(value))
+	}
+	return labelRequirement(key, operator, values)
+}
+
+func labelRequirement(key, operator string, values []string) (LabelRequirement, error) {
+	key = strings.TrimSpace(key)
+	if key == "" || strings.ContainsAny(key, " ()!=,\"") {
+		return LabelRequirement{}, fmt.Errorf("invalid label key %q", key)
+	}
+	for i := range values {
+		values[i] = strings.TrimSpace(values[i])
+	}
+	return LabelRequirement{Key: key, Operator: operator, Values: values}, nil
+}
+
+// splitSelector splits a label selector on the commas that are not within parentheses.
+func splitSelector(selector string) []string {
+	var parts []string
+	depth, start := 0, 0
+	for i, r := range selector {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				parts = append(parts, selector[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, selector[start:])
+}
+
+// PageCursor is the position after the last entity of a page. Repositories
+// encode it in EntityPage.NextCursor and resume from it.
+type PageCursor struct {
+	Sort       string `json:"s"`
+	Descending bool   `json:"d,omitempty"`
+	Value      string `json:"v"` // Sort value of the last entity
+	Ref        string `json:"r"` // Reference of the last entity
+}
+
+// Encode returns the opaque form of the cursor.
+func (c PageCursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor reads the cursor of a query, checking it was issued for the same sort.
+func DecodeCursor(cursor, sort string, descending bool) (*PageCursor, error) {
+	if cursor == "" {
+		return nil, nil
+	}
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed cursor", ErrInvalidQuery)
+	}
+	var c PageCursor
+	if err := json.Unmarshal(data, &c); err != nil || c.Ref == "" {
+		return nil, fmt.Errorf("%w: malformed cursor", ErrInvalidQuery)
+	}
+	if c.Sort != sort || c.Descending != descending {
+		return nil, fmt.Errorf("%w: the cursor was issued for a query with a different sort", ErrInvalidQuery)
+	}
+	return &c, nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,111 @@
+package ports
+
+import (
+	"encoding/base64"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParseLabelSelector(t *testing.T) {
+	tests := []struct {
+		selector string
+		want     []LabelRequirement
+		wantErr  bool
+	}{
+		{selector: "", want: nil},
+		{selector: "tier=web", want: []LabelRequirement{{Key: "tier", Operator: LabelEquals, Values: []string{"web"}}}},
+		{selector: "tier==web", want: []LabelRequirement{{Key: "tier", Operator: LabelEquals, Values: []string{"web"}}}},
+		{selector: "tier != web", want: []LabelRequirement{{Key: "tier", Operator: LabelNotEquals, Values: []string{"web"}}}},
+		{
+			selector: "app.kubernetes.io/name in (orders, billing),!legacy",
+			want: []LabelRequirement{
+				{Key: "app.kubernetes.io/name", Operator: LabelIn, Values: []string{"orders", "billing"}},
+				{Key: "legacy", Operator: LabelDoesNotExist},
+			},
+		},
+		{selector: "tier notin (web)", want: []LabelRequirement{{Key: "tier", Operator: LabelNotIn, Values: []string{"web"}}}},
+		{selector: `team\squad`, want: []LabelRequirement{{Key: `team\squad`, Operator: LabelExists}}},
+		{selector: "tier in web", wantErr: true},
+		{selector: "tier like web", wantErr: true},
+		{selector: "=web", wantErr: true},
+		{selector: `say"hi"=yes`, wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseLabelSelector(tt.selector)
+		if tt.wantErr {
+			if !errors.Is(err, ErrInvalidQuery) {
+				t.Errorf("ParseLabelSelector(%q) error = %v, want ErrInvalidQuery", tt.selector, err)
+			}
+			continue
+		}
+		if err != nil || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseLabelSelector(%q) = %+v, %v, want %+v", tt.selector, got, err, tt.want)
+		}
+	}
+}
+
+func TestParseFacet(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    Facet
+		wantErr bool
+	}{
+		{name: "kind", want: Facet{Name: "kind", Field: FacetKind}},
+		{name: "metadata.tags", want: Facet{Name: "metadata.tags", Field: FacetTags}},
+		{name: "metadata.labels.app.kubernetes.io/name", want: Facet{Name: "metadata.labels.app.kubernetes.io/name", Field: FacetLabel, Key: "app.kubernetes.io/name"}},
+		{name: "spec.owner", want: Facet{Name: "spec.owner", Field: FacetSpec, Key: "owner"}},
+		{name: "spec.owner.name", wantErr: true},
+		{name: `metadata.labels.say"hi"`, wantErr: true},
+		{name: "metadata.labels.", wantErr: true},
+		{name: "unknown", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseFacet(tt.name)
+		if tt.wantErr {
+			if !errors.Is(err, ErrInvalidQuery) {
+				t.Errorf("ParseFacet(%q) error = %v, want ErrInvalidQuery", tt.name, err)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("ParseFacet(%q) = %+v, %v, want %+v", tt.name, got, err, tt.want)
+		}
+	}
+}
+
+func TestDecodeCursor(t *testing.T) {
+	issued := PageCursor{Sort: SortByOwner, Descending: true, Value: "team-a", Ref: "component:default/orders"}
+	encode := func(data string) string { return base64.RawURLEncoding.EncodeToString([]byte(data)) }
+	tests := []struct {
+		name       string
+		cursor     string
+		sort       string
+		descending bool
+		want       *PageCursor
+		wantErr    bool
+	}{
+		{name: "no cursor", cursor: "", sort: SortByName},
+		{name: "same sort", cursor: issued.Encode(), sort: SortByOwner, descending: true, want: &issued},
+		{name: "empty sort value", cursor: PageCursor{Sort: SortByName, Ref: "api:default/x"}.Encode(), sort: SortByName, want: &PageCursor{Sort: SortByName, Ref: "api:default/x"}},
+		{name: "other sort field", cursor: issued.Encode(), sort: SortByName, descending: true, wantErr: true},
+		{name: "other direction", cursor: issued.Encode(), sort: SortByOwner, wantErr: true},
+		{name: "not base64", cursor: "%%%", sort: SortByName, wantErr: true},
+		{name: "not JSON", cursor: encode("orders"), sort: SortByName, wantErr: true},
+		{name: "without a ref", cursor: encode(`{"s":"name","v":"orders"}`), sort: SortByName, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DecodeCursor(tt.cursor, tt.sort, tt.descending)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidQuery) {
+					t.Errorf("error = %v, want ErrInvalidQuery", err)
+				}
+				return
+			}
+			if err != nil || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DecodeCursor() = %+v, %v, want %+v", got, err, tt.want)
+			}
+		})
+	}
+}
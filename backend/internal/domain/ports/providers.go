@@ -0,0 +1,28 @@
+package ports
+
+import (
+	"context"
+	"dev-compass/internal/domain/entities"
+)
+
+// SourceResult holds the entities currently declared by a single source, such as
+// one GitLab project or one catalog file.
+type SourceResult struct {
+	// Source identifies where the entities came from. It is prefixed with the
+	// provider name, e.g. "gitlab:group/project" or "file:mocks/resources.yaml".
+	Source   string
+	Entities []*entities.Entity
+	// Err is set when the source could not be read. The entities previously
+	// stored for it are then left untouched.
+	Err error
+}
+
+// EntityProvider is a source of catalog entities that discovery can be composed from.
+type EntityProvider interface {
+	// Name returns the provider name, used as the prefix of every source it reports.
+	Name() string
+	// Provide reads every source of the provider and reports each one through emit,
+	// which may be called concurrently. A nil error means all sources were listed,
+	// so entities from sources that were not reported can be removed.
+	Provide(ctx context.Context, emit func(SourceResult)) error
+}
@@ -1,10 +1,65 @@
 package ports
 
-import "dev-compass/internal/domain/entities"
+import (
+	"dev-compass/internal/domain/entities"
+	"errors"
+)
+
+// ErrNotFound is returned by repositories when the requested record does not exist.
+var ErrNotFound = errors.New("not found")
+
+// ErrConflict is returned by repositories when a conditional write finds the record changed.
+var ErrConflict = errors.New("record was changed")
 
 // EntityRepository defines the interface for entity data storage.
 type EntityRepository interface {
-	FindAll(search, tag string) ([]entities.Entity, error)
+	FindAll() ([]entities.Entity, error)
+	// Query returns the page of entities selected by a query. It returns an error
+	// wrapping ErrInvalidQuery when the query cannot be run.
+	Query(query EntityQuery) (*EntityPage, error)
+	// Facets counts the distinct values of each facet across the entities matching
+	// a query, ignoring its filter on the facet itself. Results are keyed by facet
+	// name and sorted with SortFacetCounts.
+	Facets(query EntityQuery, facets []Facet) (map[string][]FacetCount, error)
+	// Search returns the entities matching a full-text search among those selected
+	// by the filters, most relevant first. Names close to the text match even with
+	// typos. filters.Limit caps the results, its Search, Sort and Cursor are ignored.
+	Search(text string, filters EntityQuery) ([]SearchResult, error)
+	// FindByRef returns ErrNotFound when no entity has the given "kind:namespace/name" reference.
+	FindByRef(ref string) (*entities.Entity, error)
+	// Save creates the entity or replaces the stored one with the same reference.
+	// A revision is recorded when its content differs from the latest revision.
 	Save(entity *entities.Entity) error
+	// UpdateStatus replaces the status and etag of an entity, provided its etag is
+	// still etag, and leaves the rest of it alone. It returns ErrConflict when the
+	// etag changed and ErrNotFound when the entity no longer exists.
+	UpdateStatus(ref string, status *entities.EntityStatus, etag, newETag string) error
+	// FindRevisions returns the revisions of an entity, newest first, without their
+	// snapshot. Revisions are kept after the entity is deleted.
+	FindRevisions(ref string) ([]entities.EntityRevision, error)
+	// FindRevision returns ErrNotFound when the entity has no such revision.
+	FindRevision(ref string, revision int64) (*entities.EntityRevision, error)
+	Delete(ref string) error
 	DeleteAll() error
 }
+
+// LocationRepository defines the interface for storing registered locations.
+type LocationRepository interface {
+	FindAll() ([]entities.Location, error)
+	// FindByID returns ErrNotFound when no location has the given ID.
+	FindByID(id string) (*entities.Location, error)
+	Save(location *entities.Location) error
+	Delete(id string) error
+}
+
+// RelationRepository defines the interface for storing relations between entities.
+type RelationRepository interface {
+	FindAll() ([]entities.EntityRelation, error)
+	// FindBySource returns every relation going out of an entity, which includes
+	// the inverse of the relations other entities declared towards it.
+	FindBySource(ref string) ([]entities.EntityRelation, error)
+	// FindByTarget returns every relation pointing to an entity.
+	FindByTarget(ref string) ([]entities.EntityRelation, error)
+	// ReplaceForOrigin replaces every relation declared by the origin entity.
+	ReplaceForOrigin(origin string, relations []entities.EntityRelation) error
+}
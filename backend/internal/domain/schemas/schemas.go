@@ -0,0 +1,204 @@
+// Package schemas holds the JSON Schemas catalog files are validated against.
+// There is one schema per apiVersion and kind; they are embedded in the binary
+// and published through the API so editors and CI can use them too.
+package schemas
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+)
+
+//go:embed v1alpha1/*.schema.json
+var files embed.FS
+
+// baseURL is the identifier the embedded schemas are compiled under.
+const baseURL = "https://devcompass.io/schemas/"
+
+// versions maps every supported apiVersion to the directory holding its schemas.
+var versions = map[string]string{
+	"devcompass.io/v1alpha1": "v1alpha1",
+}
+
+// kinds lists every entity kind, in the order schemas are published.
+var kinds = []string{"Component", "API", "Resource", "System", "Domain", "Group", "User", "Location"}
+
+// printer formats violation messages.
+var printer = message.NewPrinter(language.English)
+
+// ErrNotFound is returned when a schema does not exist.
+var ErrNotFound = errors.New("schema not found")
+
+// Schema describes a published schema.
+type Schema struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Path       string `json:"path"` // e.g. "v1alpha1/component.schema.json"
+}
+
+// Violation is a single place where a document does not match its schema.
+type Violation struct {
+	Path    string `json:"path"` // JSON pointer to the offending value, e.g. "/spec/owner"
+	Message string `json:"message"`
+}
+
+func (v Violation) String() string {
+	if v.Path == "" {
+		return v.Message
+	}
+	return v.Path + ": " + v.Message
+}
+
+// List returns every published schema.
+func List() []Schema {
+	var list []Schema
+	for apiVersion, dir := range versions {
+		for _, kind := range kinds {
+			if name := schemaFile(dir, kind); exists(name) {
+				list = append(list, Schema{APIVersion: apiVersion, Kind: kind, Path: name})
+			}
+		}
+	}
+	return list
+}
+
+// File returns the content of a schema file, e.g. "v1alpha1/entity.schema.json".
+func File(name string) ([]byte, error) {
+	if !fs.ValidPath(name) || !strings.HasSuffix(name, ".schema.json") {
+		return nil, ErrNotFound
+	}
+	data, err := files.ReadFile(name)
+	if err != nil {
+		return nil, ErrNotFound
+	}
+	return data, nil
+}
+
+// Validate checks a decoded document against the schema of its apiVersion and
+// kind. The document must only hold JSON values. It returns no violations when
+// the document is valid.
+func Validate(apiVersion, kind string, document any) ([]Violation, error) {
+	dir, ok := versions[apiVersion]
+	if !ok {
+		return []Violation{{Path: "/apiVersion", Message: fmt.Sprintf("unsupported apiVersion %q", apiVersion)}}, nil
+	}
+	name := schemaFile(dir, kind)
+	if !isKind(kind) || !exists(name) {
+		return []Violation{{Path: "/kind", Message: fmt.Sprintf("unknown kind %q for apiVersion %s", kind, apiVersion)}}, nil
+	}
+
+	schema, err := compile(name)
+	if err != nil {
+		return nil, err
+	}
+
+	err = schema.Validate(document)
+	if err == nil {
+		return nil, nil
+	}
+	var validationErr *jsonschema.ValidationError
+	if !errors.As(err, &validationErr) {
+		return nil, err
+	}
+	// Sorted, so the same document always yields the same violations.
+	list := violations(validationErr)
+	sort.SliceStable(list, func(i, j int) bool { return list[i].Path < list[j].Path })
+	return list, nil
+}
+
+var (
+	compiler     *jsonschema.Compiler
+	compiled     = make(map[string]*jsonschema.Schema)
+	compileMutex sync.Mutex
+	compilerErr  error
+	compilerOnce sync.Once
+)
+
+// compile returns the compiled schema of a file, compiling it on first use.
+func compile(name string) (*jsonschema.Schema, error) {
+	compilerOnce.Do(func() {
+		compiler, compilerErr = newCompiler()
+	})
+	if compilerErr != nil {
+		return nil, compilerErr
+	}
+
+	compileMutex.Lock()
+	defer compileMutex.Unlock()
+	if schema, ok := compiled[name]; ok {
+		return schema, nil
+	}
+	schema, err := compiler.Compile(baseURL + name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compile schema %s: %w", name, err)
+	}
+	compiled[name] = schema
+	return schema, nil
+}
+
+// newCompiler creates a compiler that knows every embedded schema, so they can reference each other.
+func newCompiler() (*jsonschema.Compiler, error) {
+	c := jsonschema.NewCompiler()
+	err := fs.WalkDir(files, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		f, err := files.Open(name)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		doc, err := jsonschema.UnmarshalJSON(f)
+		if err != nil {
+			return fmt.Errorf("failed to parse schema %s: %w", name, err)
+		}
+		return c.AddResource(baseURL+name, doc)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// violations flattens a validation error into its leaf errors, which carry the precise location.
+func violations(err *jsonschema.ValidationError) []Violation {
+	if len(err.Causes) == 0 {
+		location := ""
+		for _, token := range err.InstanceLocation {
+			location += "/" + strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1")
+		}
+		return []Violation{{Path: location, Message: err.ErrorKind.LocalizedString(printer)}}
+	}
+	var list []Violation
+	for _, cause := range err.Causes {
+		list = append(list, violations(cause)...)
+	}
+	return list
+}
+
+// schemaFile returns the name of the schema file of a kind.
+func schemaFile(dir, kind string) string {
+	return path.Join(dir, strings.ToLower(kind)+".schema.json")
+}
+
+func isKind(kind string) bool {
+	for _, k := range kinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+func exists(name string) bool {
+	_, err := fs.Stat(files, name)
+	return err == nil
+}
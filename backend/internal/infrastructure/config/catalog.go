@@ -0,0 +1,32 @@
+package config
+
+import (
+	"log"
+	"os"
+	"strconv"
+)
+
+type Catalog struct {
+	ValidationMode string // "warn" keeps invalid entities and flags them, "reject" leaves them out
+	MaxRevisions   int    // Revisions kept per entity, the oldest ones are pruned; 0 keeps them all
+}
+
+func LoadCatalog() *Catalog {
+	validationMode, found := os.LookupEnv("CATALOG_VALIDATION_MODE")
+	if !found || (validationMode != "warn" && validationMode != "reject") {
+		log.Printf("env CATALOG_VALIDATION_MODE not found or invalid (%q) - set default value: warn", validationMode)
+		validationMode = "warn"
+	}
+
+	maxRevisions, _ := os.LookupEnv("CATALOG_MAX_REVISIONS")
+	maxRevisionsInt, err := strconv.Atoi(maxRevisions)
+	if err != nil || maxRevisionsInt < 0 {
+		maxRevisionsInt = 100
+		log.Printf("env CATALOG_MAX_REVISIONS - err: %v - set default value: %d", err, maxRevisionsInt)
+	}
+
+	return &Catalog{
+		ValidationMode: validationMode,
+		MaxRevisions:   maxRevisionsInt,
+	}
+}
@@ -2,28 +2,40 @@ package config
 
 import (
 	"log"
+	"os"
 )
 
 type Config struct {
-	App    *App
-	DB     *DB
-	GitLab *GitLab
+	App       *App
+	DB        *DB
+	GitLab    *GitLab
+	GitHub    *GitHub
+	Discovery *Discovery
+	Catalog   *Catalog
 }
 
 func Load() *Config {
-	configAws := LoadAwsCredential()
+	// Without AWS, e.g. when running standalone, the configuration comes from the environment only.
+	if _, found := os.LookupEnv("AWS_REGION"); found {
+		configAws := LoadAwsCredential()
 
-	ssmClient := SetAwsSession(*configAws)
+		ssmClient := SetAwsSession(*configAws)
 
-	ssmPath := "/ECS/DevCompass/"
-	err := LoadSSMParameters(ssmClient, ssmPath, "")
-	if err != nil {
-		log.Printf("error when loading SSM parameters: %v", err)
+		ssmPath := "/ECS/DevCompass/"
+		err := LoadSSMParameters(ssmClient, ssmPath, "")
+		if err != nil {
+			log.Printf("error when loading SSM parameters: %v", err)
+		}
+	} else {
+		log.Println("INFO: AWS_REGION not set, skipping SSM parameters")
 	}
 
 	return &Config{
-		App:    LoadApp(),
-		DB:     LoadDB(),
-		GitLab: LoadGitLab(),
+		App:       LoadApp(),
+		DB:        LoadDB(),
+		GitLab:    LoadGitLab(),
+		GitHub:    LoadGitHub(),
+		Discovery: LoadDiscovery(),
+		Catalog:   LoadCatalog(),
 	}
 }
@@ -5,7 +5,18 @@ import (
 	"os"
 )
 
+// Database drivers that can be selected with DB_DRIVER.
+const (
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite"
+)
+
+// defaultSQLitePath is the database file used by the SQLite driver when DB_PATH is not set.
+const defaultSQLitePath = "devcompass.db"
+
 type DB struct {
+	Driver string
+	Path   string // SQLite database file
 	Host,
 	Port,
 	Name,
@@ -16,6 +27,23 @@ type DB struct {
 }
 
 func LoadDB() *DB {
+	driver, driverFound := os.LookupEnv("DB_DRIVER")
+	if !driverFound || driver == "" {
+		driver = DriverPostgres
+	}
+
+	switch driver {
+	case DriverSQLite:
+		path, pathFound := os.LookupEnv("DB_PATH")
+		if !pathFound || path == "" {
+			path = defaultSQLitePath
+		}
+		return &DB{Driver: driver, Path: path}
+	case DriverPostgres:
+	default:
+		log.Fatalf("env DB_DRIVER must be %q or %q, got %q", DriverPostgres, DriverSQLite, driver)
+	}
+
 	host, hostFound := os.LookupEnv("DB_HOST")
 	if !hostFound {
 		log.Fatal("env DB_HOST not found")
@@ -52,6 +80,7 @@ func LoadDB() *DB {
 	}
 
 	return &DB{
+		Driver:   driver,
 		Host:     host,
 		Port:     port,
 		Name:     name,
@@ -0,0 +1,114 @@
+package config
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Discovery struct {
+	Interval,
+	Jitter time.Duration
+	Concurrency int
+	LocalRoot   string
+	Providers,
+	Files,
+	ExternalFiles []string
+	// AllowedHosts are the only hosts, besides the GitLab and GitHub ones, catalog
+	// files may be read from over HTTP. They may resolve to private addresses.
+	// When empty, any host with a public address may be read.
+	AllowedHosts []string
+}
+
+func LoadDiscovery() *Discovery {
+	interval, _ := os.LookupEnv("DISCOVERY_INTERVAL")
+	intervalDuration, err := time.ParseDuration(interval)
+	if err != nil {
+		intervalDuration = time.Hour
+		log.Printf("env DISCOVERY_INTERVAL - err: %v - set default value: %s", err, intervalDuration)
+	}
+
+	jitter, _ := os.LookupEnv("DISCOVERY_JITTER")
+	jitterDuration, err := time.ParseDuration(jitter)
+	if err != nil {
+		jitterDuration = 5 * time.Minute
+		log.Printf("env DISCOVERY_JITTER - err: %v - set default value: %s", err, jitterDuration)
+	}
+
+	concurrency, _ := os.LookupEnv("DISCOVERY_CONCURRENCY")
+	concurrencyInt, err := strconv.Atoi(concurrency)
+	if err != nil || concurrencyInt < 1 {
+		concurrencyInt = 4
+		log.Printf("env DISCOVERY_CONCURRENCY - err: %v - set default value: %d", err, concurrencyInt)
+	}
+
+	files, found := os.LookupEnv("CATALOG_FILES")
+	if !found {
+		files = "mocks/external-components.yaml,mocks/manual-components.yaml,mocks/resources.yaml"
+		log.Printf("env CATALOG_FILES not found - set default value: %s", files)
+	}
+
+	externalFiles, found := os.LookupEnv("CATALOG_EXTERNAL_FILES")
+	if !found {
+		externalFiles = "mocks/external-components.yaml"
+		log.Printf("env CATALOG_EXTERNAL_FILES not found - set default value: %s", externalFiles)
+	}
+
+	localRoot, found := os.LookupEnv("DISCOVERY_LOCAL_ROOT")
+	if !found {
+		localRoot = "repositories"
+		log.Printf("env DISCOVERY_LOCAL_ROOT not found - set default value: %s", localRoot)
+	}
+
+	providers, found := os.LookupEnv("DISCOVERY_PROVIDERS")
+	if !found {
+		providers = defaultProviders(localRoot)
+		log.Printf("env DISCOVERY_PROVIDERS not found - set default value: %s", providers)
+	}
+
+	allowedHosts, found := os.LookupEnv("DISCOVERY_ALLOWED_HOSTS")
+	if !found {
+		log.Println("env DISCOVERY_ALLOWED_HOSTS not found - catalog files may be read from any public host")
+	}
+
+	return &Discovery{
+		Interval:      intervalDuration,
+		Jitter:        jitterDuration,
+		Concurrency:   concurrencyInt,
+		LocalRoot:     localRoot,
+		Providers:     splitList(providers),
+		Files:         splitList(files),
+		ExternalFiles: splitList(externalFiles),
+		AllowedHosts:  splitList(allowedHosts),
+	}
+}
+
+// defaultProviders returns the providers discovery uses when none are configured.
+// GitLab is only scanned when a token is configured and the catalog is not a
+// standalone SQLite one, and local repositories when their root directory exists,
+// so a standalone server starts without any of them.
+func defaultProviders(localRoot string) string {
+	providers := []string{"file"}
+	token, _ := os.LookupEnv("GITLAB_API_TOKEN")
+	driver, _ := os.LookupEnv("DB_DRIVER")
+	if token != "" && driver != DriverSQLite {
+		providers = append(providers, "gitlab")
+	}
+	if info, err := os.Stat(localRoot); err == nil && info.IsDir() {
+		providers = append(providers, "local")
+	}
+	return strings.Join(providers, ",")
+}
+
+// splitList splits a comma-separated env value, dropping empty items.
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
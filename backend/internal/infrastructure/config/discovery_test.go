@@ -0,0 +1,33 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDefaultProviders(t *testing.T) {
+	root := t.TempDir()
+	tests := []struct {
+		name, token, driver, localRoot, want string
+	}{
+		{"standalone", "", DriverSQLite, filepath.Join(root, "missing"), "file"},
+		{"standalone with local repositories", "", DriverSQLite, root, "file,local"},
+		{"SQLite with a GitLab token", "secret", DriverSQLite, root, "file,local"},
+		{"Postgres without a GitLab token", "", DriverPostgres, filepath.Join(root, "missing"), "file"},
+		{"Postgres with a GitLab token", "secret", DriverPostgres, filepath.Join(root, "missing"), "file,gitlab"},
+		{"default driver with a GitLab token", "secret", "", root, "file,gitlab,local"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("GITLAB_API_TOKEN", tt.token)
+			t.Setenv("DB_DRIVER", tt.driver)
+			if tt.driver == "" {
+				os.Unsetenv("DB_DRIVER")
+			}
+			if got := defaultProviders(tt.localRoot); got != tt.want {
+				t.Errorf("defaultProviders() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
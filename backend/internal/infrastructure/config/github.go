@@ -0,0 +1,43 @@
+package config
+
+import (
+	"log"
+	"os"
+	"strconv"
+)
+
+type GitHub struct {
+	APIURL       string
+	Token        string
+	Organization string
+	MaxListItems int
+}
+
+func LoadGitHub() *GitHub {
+	apiURL, found := os.LookupEnv("GITHUB_API_URL")
+	if !found {
+		apiURL = "https://api.github.com"
+	}
+
+	token, found := os.LookupEnv("GITHUB_TOKEN")
+	if !found {
+		// Public repositories can be read without a token, with a much lower rate limit.
+		log.Println("WARN: env GITHUB_TOKEN not found. GitHub discovery will use unauthenticated requests.")
+	}
+
+	org, _ := os.LookupEnv("GITHUB_ORG")
+
+	maxListItems, _ := os.LookupEnv("GITHUB_MAX_LIST_ITEMS")
+	maxListItemsInt, err := strconv.Atoi(maxListItems)
+	if err != nil {
+		maxListItemsInt = 10000
+		log.Printf("env GITHUB_MAX_LIST_ITEMS - err: %v - set default value: %d", err, maxListItemsInt)
+	}
+
+	return &GitHub{
+		APIURL:       apiURL,
+		Token:        token,
+		Organization: org,
+		MaxListItems: maxListItemsInt,
+	}
+}
@@ -3,18 +3,22 @@ package config
 import (
 	"log"
 	"os"
+	"strconv"
 )
 
 type GitLab struct {
-	Token       string
-	GroupToScan string
+	Token         string
+	GroupToScan   string
+	MaxListItems  int
+	WebhookSecret string
+	CatalogPaths  []string
 }
 
 func LoadGitLab() *GitLab {
 	token, found := os.LookupEnv("GITLAB_API_TOKEN")
 	if !found {
 		// No hacemos log.Fatal aquí, porque solo es necesario para el seeding.
-		log.Println("WARN: env GITLAB_API_TOKEN not found. Discovery process will not work.")
+		log.Println("WARN: env GITLAB_API_TOKEN not found. GitLab discovery will not work.")
 	}
 
 	group, found := os.LookupEnv("GITLAB_GROUP_TO_SCAN")
@@ -22,8 +26,28 @@ func LoadGitLab() *GitLab {
 		log.Println("WARN: env GITLAB_GROUP_TO_SCAN not found. Discovery process will not work.")
 	}
 
+	maxListItems, _ := os.LookupEnv("GITLAB_MAX_LIST_ITEMS")
+	maxListItemsInt, err := strconv.Atoi(maxListItems)
+	if err != nil {
+		maxListItemsInt = 10000
+		log.Printf("env GITLAB_MAX_LIST_ITEMS - err: %v - set default value: %d", err, maxListItemsInt)
+	}
+
+	webhookSecret, found := os.LookupEnv("GITLAB_WEBHOOK_SECRET")
+	if !found {
+		log.Println("WARN: env GITLAB_WEBHOOK_SECRET not found. GitLab webhooks will be rejected.")
+	}
+
+	catalogPaths, found := os.LookupEnv("GITLAB_CATALOG_PATHS")
+	if !found {
+		log.Println("env GITLAB_CATALOG_PATHS not found - only the catalog file at the repository root will be read")
+	}
+
 	return &GitLab{
-		Token:       token,
-		GroupToScan: group,
+		Token:         token,
+		GroupToScan:   group,
+		MaxListItems:  maxListItemsInt,
+		WebhookSecret: webhookSecret,
+		CatalogPaths:  splitList(catalogPaths),
 	}
 }
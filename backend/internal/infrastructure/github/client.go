@@ -0,0 +1,233 @@
+package github
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// ErrNotFound is returned when the requested resource does not exist.
+var ErrNotFound = errors.New("404 Not Found")
+
+// Client is a minimal client for the GitHub REST API, covering what discovery needs.
+type Client struct {
+	baseURL    string
+	token      string
+	httpClient *http.Client
+}
+
+// NewClient creates a new GitHub client. The baseURL can point to GitHub Enterprise
+// or to a local fake API server.
+func NewClient(baseURL, token string) *Client {
+	return &Client{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		token:      token,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// Repository is a GitHub repository.
+type Repository struct {
+	Name          string `json:"name"`
+	FullName      string `json:"full_name"`
+	Description   string `json:"description"`
+	HTMLURL       string `json:"html_url"`
+	DefaultBranch string `json:"default_branch"`
+	Archived      bool   `json:"archived"`
+}
+
+// Release is a published release of a repository.
+type Release struct {
+	TagName     string    `json:"tag_name"`
+	Name        string    `json:"name"`
+	HTMLURL     string    `json:"html_url"`
+	Draft       bool      `json:"draft"`
+	Prerelease  bool      `json:"prerelease"`
+	CreatedAt   time.Time `json:"created_at"`
+	PublishedAt time.Time `json:"published_at"`
+}
+
+// Tag is a git tag of a repository.
+type Tag struct {
+	Name   string `json:"name"`
+	Commit struct {
+		SHA string `json:"sha"`
+	} `json:"commit"`
+}
+
+// WorkflowRun is a single run of a GitHub Actions workflow.
+type WorkflowRun struct {
+	ID         int64     `json:"id"`
+	Name       string    `json:"name"`
+	HeadBranch string    `json:"head_branch"`
+	HeadSHA    string    `json:"head_sha"`
+	Status     string    `json:"status"`
+	Conclusion string    `json:"conclusion"`
+	HTMLURL    string    `json:"html_url"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type fileContent struct {
+	Content  string `json:"content"`
+	Encoding string `json:"encoding"`
+}
+
+// ListOrgRepositories lists the repositories of an organization, following
+// pagination up to maxItems repositories (zero means no limit). The boolean
+// result reports whether the listing was truncated.
+func (c *Client) ListOrgRepositories(ctx context.Context, org string, maxItems int) ([]Repository, bool, error) {
+	return listAll[Repository](ctx, c, fmt.Sprintf("/orgs/%s/repos?type=all&per_page=100", url.PathEscape(org)), maxItems)
+}
+
+// ListReleases lists the most recent releases of a repository, newest first.
+func (c *Client) ListReleases(ctx context.Context, owner, repo string, perPage int) ([]Release, error) {
+	var page []Release
+	path := fmt.Sprintf("/repos/%s/%s/releases?per_page=%d", url.PathEscape(owner), url.PathEscape(repo), perPage)
+	if _, err := c.get(ctx, path, &page); err != nil {
+		return nil, err
+	}
+	return page, nil
+}
+
+// ListTags lists the first page of the tags of a repository.
+func (c *Client) ListTags(ctx context.Context, owner, repo string, perPage int) ([]Tag, error) {
+	var page []Tag
+	path := fmt.Sprintf("/repos/%s/%s/tags?per_page=%d", url.PathEscape(owner), url.PathEscape(repo), perPage)
+	if _, err := c.get(ctx, path, &page); err != nil {
+		return nil, err
+	}
+	return page, nil
+}
+
+// ListWorkflowRuns lists the most recent workflow runs of a branch.
+func (c *Client) ListWorkflowRuns(ctx context.Context, owner, repo, branch string, perPage int) ([]WorkflowRun, error) {
+	var page struct {
+		WorkflowRuns []WorkflowRun `json:"workflow_runs"`
+	}
+	path := fmt.Sprintf("/repos/%s/%s/actions/runs?branch=%s&per_page=%d", url.PathEscape(owner), url.PathEscape(repo), url.QueryEscape(branch), perPage)
+	if _, err := c.get(ctx, path, &page); err != nil {
+		return nil, err
+	}
+	return page.WorkflowRuns, nil
+}
+
+// GetFile returns the decoded content of a file at the given ref.
+func (c *Client) GetFile(ctx context.Context, owner, repo, filePath, ref string) ([]byte, error) {
+	path := fmt.Sprintf("/repos/%s/%s/contents/%s?ref=%s", url.PathEscape(owner), url.PathEscape(repo), escapePath(filePath), url.QueryEscape(ref))
+	return c.getContent(ctx, path)
+}
+
+// GetReadme returns the decoded content of the preferred README of a repository.
+func (c *Client) GetReadme(ctx context.Context, owner, repo, ref string) ([]byte, error) {
+	path := fmt.Sprintf("/repos/%s/%s/readme?ref=%s", url.PathEscape(owner), url.PathEscape(repo), url.QueryEscape(ref))
+	return c.getContent(ctx, path)
+}
+
+func (c *Client) getContent(ctx context.Context, path string) ([]byte, error) {
+	var file fileContent
+	if _, err := c.get(ctx, path, &file); err != nil {
+		return nil, err
+	}
+	if file.Encoding != "base64" {
+		return []byte(file.Content), nil
+	}
+	return base64.StdEncoding.DecodeString(strings.ReplaceAll(file.Content, "\n", ""))
+}
+
+// linkNextRegex extracts the next page URL from a Link response header.
+var linkNextRegex = regexp.MustCompile(`<([^>]+)>;\s*rel="next"`)
+
+// listAll follows the Link headers of a paginated listing.
+func listAll[T any](ctx context.Context, c *Client, path string, maxItems int) ([]T, bool, error) {
+	var items []T
+	next := c.baseURL + path
+	for next != "" {
+		var page []T
+		header, err := c.get(ctx, next, &page)
+		if err != nil {
+			return nil, false, err
+		}
+		for _, item := range page {
+			if maxItems > 0 && len(items) >= maxItems {
+				return items, true, nil
+			}
+			items = append(items, item)
+		}
+
+		next = ""
+		if matches := linkNextRegex.FindStringSubmatch(header.Get("Link")); len(matches) > 1 {
+			// The token goes along with the request, so the next page must be on the API host.
+			if !c.sameOrigin(matches[1]) {
+				return nil, false, fmt.Errorf("next page %s is not on %s", matches[1], c.baseURL)
+			}
+			next = matches[1]
+		}
+	}
+	return items, false, nil
+}
+
+// sameOrigin reports whether an absolute URL has the scheme and host of the base URL.
+func (c *Client) sameOrigin(target string) bool {
+	base, err := url.Parse(c.baseURL)
+	if err != nil {
+		return false
+	}
+	parsed, err := url.Parse(target)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(parsed.Scheme, base.Scheme) && strings.EqualFold(parsed.Host, base.Host)
+}
+
+// get performs a GET request against a path relative to the base URL, or an
+// absolute URL, and decodes the JSON response into v.
+func (c *Client) get(ctx context.Context, path string, v any) (http.Header, error) {
+	target := path
+	if !strings.HasPrefix(path, "http://") && !strings.HasPrefix(path, "https://") {
+		target = c.baseURL + path
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, ErrNotFound
+	case resp.StatusCode >= 300:
+		return nil, fmt.Errorf("GET %s: %s", target, resp.Status)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return nil, fmt.Errorf("failed to decode response of GET %s: %w", target, err)
+	}
+	return resp.Header, nil
+}
+
+// escapePath escapes each segment of a repository file path.
+func escapePath(filePath string) string {
+	segments := strings.Split(strings.TrimPrefix(filePath, "/"), "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return strings.Join(segments, "/")
+}
@@ -0,0 +1,69 @@
+package apis
+
+import (
+	"dev-compass/internal/application"
+	"dev-compass/internal/domain/ports"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strings"
+)
+
+// Handler handles HTTP requests for API entities.
+type Handler struct {
+	service *application.APIService
+}
+
+// NewHandler creates a new APIs handler.
+func NewHandler(service *application.APIService) *Handler {
+	return &Handler{service: service}
+}
+
+// GetAPI handles the request to get an API with its providers and consumers.
+func (h *Handler) GetAPI(c *gin.Context) {
+	api, err := h.service.GetAPI(c.Param("namespace"), c.Param("name"))
+	if err != nil {
+		if errors.Is(err, ports.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "API not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, api)
+}
+
+// GetDefinition handles the request to get the raw definition of an API.
+func (h *Handler) GetDefinition(c *gin.Context) {
+	apiType, definition, err := h.service.GetDefinition(c.Param("namespace"), c.Param("name"))
+	if err != nil {
+		if errors.Is(err, ports.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "API not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if definition == "" {
+		c.JSON(http.StatusNotFound, gin.H{"error": "API has no definition"})
+		return
+	}
+
+	c.Data(http.StatusOK, definitionContentType(apiType, definition), []byte(definition))
+}
+
+// definitionContentType guesses the media type of a definition from the API type and its content.
+func definitionContentType(apiType, definition string) string {
+	switch strings.ToLower(apiType) {
+	case "openapi", "asyncapi":
+		if trimmed := strings.TrimSpace(definition); strings.HasPrefix(trimmed, "{") {
+			return "application/json; charset=utf-8"
+		}
+		return "application/yaml; charset=utf-8"
+	case "graphql":
+		return "application/graphql; charset=utf-8"
+	default:
+		return "text/plain; charset=utf-8"
+	}
+}
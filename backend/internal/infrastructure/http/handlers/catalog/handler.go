@@ -2,30 +2,339 @@ package catalog
 
 import (
 	"dev-compass/internal/application"
+	"dev-compass/internal/domain/entities"
+	"dev-compass/internal/domain/ports"
+	"errors"
+	"fmt"
 	"github.com/gin-gonic/gin"
+	"io"
 	"net/http"
+	"strconv"
+	"strings"
 )
 
 // Handler handles HTTP requests for the catalog.
 type Handler struct {
 	service *application.CatalogService
+	manual  *application.ManualEntityService
 }
 
 // NewHandler creates a new catalog handler.
-func NewHandler(service *application.CatalogService) *Handler {
-	return &Handler{service: service}
+func NewHandler(service *application.CatalogService, manual *application.ManualEntityService) *Handler {
+	return &Handler{service: service, manual: manual}
 }
 
-// GetAllEntities handles the request to get all entities.
+// GetAllEntities handles the request to list entities. The query parameters filter them:
+//
+//	search         text in the name or description
+//	kind, owner, lifecycle, type
+//	               any of the given values, repeated or comma-separated
+//	tag            every given tag, repeated or comma-separated
+//	labelSelector  Kubernetes-style selector, e.g. "tier=web,env in (prod,uat)"
+//	annotation     key of an annotation that must be set, may be repeated
+//
+// sort takes one of name, namespace, kind, owner, lifecycle or type, prefixed
+// with "-" for descending order. With limit, the X-Next-Cursor header holds the
+// cursor of the next page. X-Total-Count is the number of matching entities.
 func (h *Handler) GetAllEntities(c *gin.Context) {
-	search := c.Query("search")
-	tag := c.Query("tag")
+	query, err := entityQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	page, err := h.service.QueryEntities(query)
+	if err != nil {
+		if errors.Is(err, ports.ErrInvalidQuery) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("X-Total-Count", strconv.FormatInt(page.TotalCount, 10))
+	if page.NextCursor != "" {
+		c.Header("X-Next-Cursor", page.NextCursor)
+	}
+	c.JSON(http.StatusOK, page.Entities)
+}
+
+// GetFacets handles the request to count the values of the facets named by the
+// "facet" query parameter, e.g. "tags", "kind" or "spec.owner", across the entities
+// matching the same filters as GetAllEntities. The filter on a facet itself is
+// ignored when counting it, so every value that can be picked is listed.
+func (h *Handler) GetFacets(c *gin.Context) {
+	query, err := entityQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	names := queryList(c, "facet")
+	if len(names) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "at least one facet is required"})
+		return
+	}
+	var facets []ports.Facet
+	for _, name := range names {
+		facet, err := ports.ParseFacet(name)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		facets = append(facets, facet)
+	}
+
+	counts, err := h.service.GetFacets(query, facets)
+	if err != nil {
+		if errors.Is(err, ports.ErrInvalidQuery) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"facets": counts})
+}
+
+// Search handles a full-text search over the names, descriptions, tags,
+// annotations and READMEs of entities. The text is the "q" query parameter; the
+// filters of GetAllEntities apply. Results are ranked, with highlighted snippets.
+func (h *Handler) Search(c *gin.Context) {
+	text := strings.TrimSpace(c.Query("q"))
+	if text == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "the q parameter is required"})
+		return
+	}
+	filters, err := entityQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if c.Query("limit") == "" {
+		filters.Limit = defaultSearchLimit
+	}
+
+	results, err := h.service.Search(text, filters)
+	if err != nil {
+		if errors.Is(err, ports.ErrInvalidQuery) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, results)
+}
+
+// GetEntity handles the request to get a single entity by its kind, namespace and name.
+func (h *Handler) GetEntity(c *gin.Context) {
+	entity, err := h.service.GetEntity(c.Param("kind"), c.Param("namespace"), c.Param("name"))
+	if err != nil {
+		if errors.Is(err, ports.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "entity not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	setETag(c, entity)
+	c.JSON(http.StatusOK, entity)
+}
+
+// GetRelations handles the request to list the relations of an entity, optionally
+// filtered by the "type" query parameter.
+func (h *Handler) GetRelations(c *gin.Context) {
+	relations, err := h.service.GetRelations(c.Param("kind"), c.Param("namespace"), c.Param("name"), c.Query("type"))
+	if err != nil {
+		if errors.Is(err, ports.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "entity not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, relations)
+}
+
+// GetHistory handles the request to list the revisions of an entity, newest first.
+// The history of a deleted entity can still be listed.
+func (h *Handler) GetHistory(c *gin.Context) {
+	revisions, err := h.service.GetHistory(c.Param("kind"), c.Param("namespace"), c.Param("name"))
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, revisions)
+}
+
+// GetRevision handles the request to get a single revision of an entity, with the entity as it was then.
+func (h *Handler) GetRevision(c *gin.Context) {
+	number, err := strconv.ParseInt(c.Param("revision"), 10, 64)
+	if err != nil || number < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "revision must be a positive integer"})
+		return
+	}
+
+	revision, err := h.service.GetRevision(c.Param("kind"), c.Param("namespace"), c.Param("name"), number)
+	if err != nil {
+		if errors.Is(err, ports.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "revision not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, revision)
+}
 
-	entities, err := h.service.GetAllEntities(search, tag)
+// DiffRevisions handles the request to compare two revisions of an entity, given
+// by the "from" and "to" query parameters. to defaults to the latest revision and
+// from to the one before to.
+func (h *Handler) DiffRevisions(c *gin.Context) {
+	var numbers [2]int64
+	for i, param := range []string{"from", "to"} {
+		value := c.Query(param)
+		if value == "" {
+			continue
+		}
+		number, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || number < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("%s must be a positive integer", param)})
+			return
+		}
+		numbers[i] = number
+	}
+
+	diff, err := h.service.DiffRevisions(c.Param("kind"), c.Param("namespace"), c.Param("name"), numbers[0], numbers[1])
 	if err != nil {
+		if errors.Is(err, ports.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "revision not found"})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, entities)
+	c.JSON(http.StatusOK, diff)
+}
+
+// PutEntity handles the request to create or replace a manual entity. The body is
+// the entity as JSON or YAML. An If-Match header makes the write conditional on
+// the etag of the stored entity.
+func (h *Handler) PutEntity(c *gin.Context) {
+	body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxEntitySize))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("entity is larger than %d bytes", maxEntitySize)})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "could not read the request body"})
+		return
+	}
+
+	entity, created, err := h.manual.PutEntity(c.Request.Context(), c.Param("kind"), c.Param("namespace"), c.Param("name"), body, c.GetHeader("If-Match"))
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	setETag(c, entity)
+	if created {
+		c.JSON(http.StatusCreated, entity)
+		return
+	}
+	c.JSON(http.StatusOK, entity)
+}
+
+// DeleteEntity handles the request to delete a manual entity, conditional on the If-Match header when set.
+func (h *Handler) DeleteEntity(c *gin.Context) {
+	if err := h.manual.DeleteEntity(c.Param("kind"), c.Param("namespace"), c.Param("name"), c.GetHeader("If-Match")); err != nil {
+		writeError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// defaultSearchLimit is the number of search results returned when no limit is given.
+const defaultSearchLimit = 20
+
+// maxQueryLimit is the largest page of entities that can be requested.
+const maxQueryLimit = 1000
+
+// entityQuery reads an entity query from the query parameters of a request.
+func entityQuery(c *gin.Context) (ports.EntityQuery, error) {
+	query := ports.EntityQuery{
+		Search:      c.Query("search"),
+		Kinds:       queryList(c, "kind"),
+		Owners:      queryList(c, "owner"),
+		Lifecycles:  queryList(c, "lifecycle"),
+		Types:       queryList(c, "type"),
+		Tags:        queryList(c, "tag"),
+		Annotations: queryList(c, "annotation"),
+		Cursor:      c.Query("cursor"),
+	}
+
+	selector, err := ports.ParseLabelSelector(c.Query("labelSelector"))
+	if err != nil {
+		return query, err
+	}
+	query.LabelSelector = selector
+
+	query.Sort, query.Descending = strings.CutPrefix(c.Query("sort"), "-")
+	if _, err := query.SortField(); err != nil {
+		return query, err
+	}
+
+	if limit := c.Query("limit"); limit != "" {
+		query.Limit, err = strconv.Atoi(limit)
+		if err != nil || query.Limit < 1 || query.Limit > maxQueryLimit {
+			return query, fmt.Errorf("limit must be a number between 1 and %d", maxQueryLimit)
+		}
+	}
+	return query, nil
+}
+
+// queryList returns the values of a query parameter, which may be repeated or comma-separated.
+func queryList(c *gin.Context, name string) []string {
+	var values []string
+	for _, param := range c.QueryArray(name) {
+		for _, value := range strings.Split(param, ",") {
+			if value = strings.TrimSpace(value); value != "" {
+				values = append(values, value)
+			}
+		}
+	}
+	return values
+}
+
+// maxEntitySize limits the size of an entity sent through the API.
+const maxEntitySize = 1 << 20
+
+func setETag(c *gin.Context, entity *entities.Entity) {
+	if entity.Metadata.ETag != "" {
+		c.Header("ETag", strconv.Quote(entity.Metadata.ETag))
+	}
+}
+
+func writeError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ports.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "entity not found"})
+	case errors.Is(err, application.ErrInvalidEntity), errors.Is(err, application.ErrEntityMismatch):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, application.ErrEntityNotManual):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, application.ErrPreconditionFailed):
+		c.JSON(http.StatusPreconditionFailed, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
 }
@@ -0,0 +1,47 @@
+package discovery
+
+import (
+	"dev-compass/internal/application"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+// Handler handles HTTP requests for the discovery scheduler.
+type Handler struct {
+	scheduler *application.DiscoveryScheduler
+}
+
+// NewHandler creates a new discovery handler. A nil scheduler means discovery is not configured.
+func NewHandler(scheduler *application.DiscoveryScheduler) *Handler {
+	return &Handler{scheduler: scheduler}
+}
+
+// GetStatus handles the request to get the state of the discovery scheduler.
+func (h *Handler) GetStatus(c *gin.Context) {
+	if h.scheduler == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "discovery is not configured"})
+		return
+	}
+
+	c.JSON(http.StatusOK, h.scheduler.Status())
+}
+
+// TriggerRun handles the request to start a discovery run immediately.
+func (h *Handler) TriggerRun(c *gin.Context) {
+	if h.scheduler == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "discovery is not configured"})
+		return
+	}
+
+	if err := h.scheduler.TriggerNow(); err != nil {
+		if errors.Is(err, application.ErrDiscoveryInProgress) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, h.scheduler.Status())
+}
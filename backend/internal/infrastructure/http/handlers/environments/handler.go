@@ -41,6 +41,17 @@ func (h *Handler) GetEnvironments(c *gin.Context) {
 	c.JSON(http.StatusOK, environments)
 }
 
+// GetEnvironmentsByEntity handles the request to get environment data for an entity identified by kind, namespace and name.
+func (h *Handler) GetEnvironmentsByEntity(c *gin.Context) {
+	environments, err := h.service.GetEnvironmentsByEntity(c.Param("kind"), c.Param("namespace"), c.Param("name"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, environments)
+}
+
 // GetEnvironmentsByComponent handles the request to get environment data for a specific component.
 func (h *Handler) GetEnvironmentsByComponent(c *gin.Context) {
 	componentName := c.Param("componentName")
@@ -0,0 +1,46 @@
+package groups
+
+import (
+	"dev-compass/internal/application"
+	"dev-compass/internal/domain/entities"
+	"dev-compass/internal/domain/ports"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+// Handler handles HTTP requests for Group entities.
+type Handler struct {
+	service *application.OwnerService
+}
+
+// NewHandler creates a new groups handler.
+func NewHandler(service *application.OwnerService) *Handler {
+	return &Handler{service: service}
+}
+
+// GetOwnedEntities handles the request to list everything a group and its sub-groups own.
+func (h *Handler) GetOwnedEntities(c *gin.Context) {
+	h.getOwnedEntities(c, c.Param("namespace"), c.Param("name"))
+}
+
+// GetDefaultNamespaceOwnedEntities handles /groups/:name/owned, the short form of
+// GetOwnedEntities for groups in the default namespace. Gin requires both routes
+// to give their first segment the same name, so the group name is read from "namespace".
+func (h *Handler) GetDefaultNamespaceOwnedEntities(c *gin.Context) {
+	h.getOwnedEntities(c, entities.DefaultNamespace, c.Param("namespace"))
+}
+
+func (h *Handler) getOwnedEntities(c *gin.Context, namespace, name string) {
+	owned, err := h.service.GetOwnedEntities(namespace, name)
+	if err != nil {
+		if errors.Is(err, ports.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "group not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, owned)
+}
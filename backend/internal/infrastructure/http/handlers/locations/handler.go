@@ -0,0 +1,90 @@
+package locations
+
+import (
+	"dev-compass/internal/application"
+	"dev-compass/internal/domain/ports"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+// Handler handles HTTP requests for registered locations.
+type Handler struct {
+	service *application.LocationService
+}
+
+// NewHandler creates a new locations handler.
+func NewHandler(service *application.LocationService) *Handler {
+	return &Handler{service: service}
+}
+
+type registerLocationRequest struct {
+	Target string `json:"target" binding:"required"`
+}
+
+// GetAllLocations handles the request to list every registered location.
+func (h *Handler) GetAllLocations(c *gin.Context) {
+	locations, err := h.service.GetAllLocations()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, locations)
+}
+
+// GetLocation handles the request to get a single registered location.
+func (h *Handler) GetLocation(c *gin.Context) {
+	location, err := h.service.GetLocation(c.Param("id"))
+	if err != nil {
+		if errors.Is(err, ports.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "location not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, location)
+}
+
+// RegisterLocation handles the request to register a catalog file URL.
+func (h *Handler) RegisterLocation(c *gin.Context) {
+	var req registerLocationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	location, report, err := h.service.RegisterLocation(c.Request.Context(), req.Target)
+	if err != nil {
+		switch {
+		case errors.Is(err, application.ErrInvalidLocation), errors.Is(err, application.ErrLocationUnreadable):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, application.ErrLocationNotAllowed):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case errors.Is(err, application.ErrLocationExists):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"location": location, "report": report})
+}
+
+// UnregisterLocation handles the request to remove a registered location and its entities.
+func (h *Handler) UnregisterLocation(c *gin.Context) {
+	report, err := h.service.UnregisterLocation(c.Param("id"))
+	if err != nil {
+		if errors.Is(err, ports.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "location not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,42 @@
+package schemas
+
+import (
+	"dev-compass/internal/domain/schemas"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"path"
+)
+
+// Handler publishes the JSON Schemas catalog files are validated against.
+type Handler struct{}
+
+// NewHandler creates a new schemas handler.
+func NewHandler() *Handler {
+	return &Handler{}
+}
+
+// schemaLink is a published schema along with the URL it is served from.
+type schemaLink struct {
+	schemas.Schema
+	URL string `json:"url"`
+}
+
+// GetAllSchemas handles the request to list the schema of every apiVersion and kind.
+func (h *Handler) GetAllSchemas(c *gin.Context) {
+	var links []schemaLink
+	for _, schema := range schemas.List() {
+		links = append(links, schemaLink{Schema: schema, URL: "/api/v1/schemas/" + schema.Path})
+	}
+	c.JSON(http.StatusOK, links)
+}
+
+// GetSchema handles the request to get a single schema file. Schemas reference
+// each other with relative URLs, so they can be used straight from this endpoint.
+func (h *Handler) GetSchema(c *gin.Context) {
+	data, err := schemas.File(path.Join(c.Param("version"), c.Param("file")))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "schema not found"})
+		return
+	}
+	c.Data(http.StatusOK, "application/schema+json", data)
+}
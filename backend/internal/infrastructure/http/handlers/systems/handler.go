@@ -0,0 +1,46 @@
+package systems
+
+import (
+	"dev-compass/internal/application"
+	"dev-compass/internal/domain/entities"
+	"dev-compass/internal/domain/ports"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+// Handler handles HTTP requests for System entities.
+type Handler struct {
+	service *application.SystemService
+}
+
+// NewHandler creates a new systems handler.
+func NewHandler(service *application.SystemService) *Handler {
+	return &Handler{service: service}
+}
+
+// GetSystem handles the request to get the aggregated view of a system.
+func (h *Handler) GetSystem(c *gin.Context) {
+	h.getSystem(c, c.Param("namespace"), c.Param("name"))
+}
+
+// GetDefaultNamespaceSystem handles /systems/:name, the short form of GetSystem
+// for systems in the default namespace. Gin requires both routes to give their
+// first segment the same name, so the system name is read from "namespace".
+func (h *Handler) GetDefaultNamespaceSystem(c *gin.Context) {
+	h.getSystem(c, entities.DefaultNamespace, c.Param("namespace"))
+}
+
+func (h *Handler) getSystem(c *gin.Context, namespace, name string) {
+	system, err := h.service.GetSystem(namespace, name)
+	if err != nil {
+		if errors.Is(err, ports.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "system not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, system)
+}
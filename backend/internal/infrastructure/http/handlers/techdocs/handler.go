@@ -1,47 +1,75 @@
 package techdocs
 
 import (
+	"dev-compass/internal/application"
+	"dev-compass/internal/domain/entities"
+	"dev-compass/internal/domain/ports"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/gin-gonic/gin"
+	"io/fs"
 	"net/http"
 	"os"
+	"path"
 	"path/filepath"
+	"strings"
 )
 
 // Handler handles HTTP requests for TechDocs.
-type Handler struct{}
+type Handler struct {
+	catalog *application.CatalogService
+	docs    *application.TechDocsReader
+}
 
 // NewHandler creates a new TechDocs handler.
-func NewHandler() *Handler {
-	return &Handler{}
+func NewHandler(catalog *application.CatalogService, docs *application.TechDocsReader) *Handler {
+	return &Handler{catalog: catalog, docs: docs}
 }
 
 // GetDoc handles the request to get a documentation file.
 func (h *Handler) GetDoc(c *gin.Context) {
-	componentName := c.Param("name")
-	docPath := c.Param("path")
-
 	// Basic security check to prevent directory traversal
-	if docPath == "" || docPath[0] == '.' {
+	docPath := path.Clean("/" + c.Param("path"))
+	if docPath == "/" || strings.Contains(c.Param("path"), "..") {
 		c.String(http.StatusBadRequest, "Invalid path.")
 		return
 	}
 
-	// In a real implementation, you would first look up the component
-	// in the database to find its configured docs directory.
-	// For this mock, we assume the docs dir is always "docs".
-	assumedDocsDir := "docs"
-
-	// Construct the path to the mock file
-	// Example: mocks/mock_repos/auth-service/docs/index.md
-	fullPath := filepath.Join("mocks", "mock_repos", componentName, assumedDocsDir, docPath)
+	entity, err := h.catalog.GetEntity(c.Param("kind"), c.Param("namespace"), c.Param("name"))
+	if err != nil {
+		if errors.Is(err, ports.ErrNotFound) {
+			c.String(http.StatusNotFound, "Entity not found.")
+		} else {
+			c.String(http.StatusInternalServerError, fmt.Sprintf("Error reading entity: %v", err))
+		}
+		return
+	}
 
-	content, err := os.ReadFile(fullPath)
+	// Entities with a techdocs-ref annotation are documented where it points to.
+	content, annotated, err := h.docs.Read(c.Request.Context(), entity, docPath)
+	if !annotated {
+		// Components may declare their docs directory; the rest default to "docs".
+		docsDir := "docs"
+		var spec entities.ComponentSpec
+		if err := json.Unmarshal(entity.Spec, &spec); err == nil && spec.TechDocs.Dir != "" {
+			docsDir = spec.TechDocs.Dir
+		}
+		if strings.Contains(docsDir, "..") {
+			c.String(http.StatusBadRequest, "Invalid docs directory.")
+			return
+		}
+		// Example: mocks/mock_repos/auth-service/docs/index.md
+		content, err = os.ReadFile(filepath.Join("mocks", "mock_repos", entity.Metadata.Name, docsDir, filepath.FromSlash(docPath)))
+	}
 	if err != nil {
-		if os.IsNotExist(err) {
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
 			c.String(http.StatusNotFound, "Documentation file not found.")
-		} else {
-			c.String(http.StatusInternalServerError, fmt.Sprintf("Error reading file: %v", err))
+		case errors.Is(err, application.ErrTechDocsUnavailable):
+			c.String(http.StatusNotImplemented, fmt.Sprintf("Documentation is not available for this entity: %v", err))
+		default:
+			c.String(http.StatusInternalServerError, fmt.Sprintf("Error reading documentation: %v", err))
 		}
 		return
 	}
@@ -0,0 +1,94 @@
+package webhooks
+
+import (
+	"crypto/subtle"
+	"dev-compass/internal/application"
+	"github.com/gin-gonic/gin"
+	gitlab "gitlab.com/gitlab-org/api/client-go"
+	"io"
+	"log"
+	"net/http"
+)
+
+// maxPayloadSize bounds the size of webhook bodies; GitLab push events for large pushes stay well below it.
+const maxPayloadSize = 5 << 20
+
+// Handler handles incoming webhooks from source control systems.
+type Handler struct {
+	discovery    *application.DiscoveryService
+	refresher    *application.ProjectRefresher
+	gitlabSecret string
+}
+
+// NewHandler creates a new webhooks handler. A nil discovery service means discovery is not configured.
+func NewHandler(discovery *application.DiscoveryService, refresher *application.ProjectRefresher, gitlabSecret string) *Handler {
+	return &Handler{discovery: discovery, refresher: refresher, gitlabSecret: gitlabSecret}
+}
+
+// HandleGitLab handles push, tag, pipeline and deployment events by refreshing the affected project.
+func (h *Handler) HandleGitLab(c *gin.Context) {
+	if h.discovery == nil || h.gitlabSecret == "" {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "GitLab webhooks are not configured"})
+		return
+	}
+
+	token := gitlab.HookEventToken(c.Request)
+	if subtle.ConstantTimeCompare([]byte(token), []byte(h.gitlabSecret)) != 1 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid webhook token"})
+		return
+	}
+
+	payload, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxPayloadSize))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "could not read payload: " + err.Error()})
+		return
+	}
+
+	eventType := gitlab.HookEventType(c.Request)
+	switch eventType {
+	case gitlab.EventTypePush, gitlab.EventTypeTagPush, gitlab.EventTypePipeline, gitlab.EventTypeDeployment:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "unsupported event type: " + string(eventType)})
+		return
+	}
+
+	event, err := gitlab.ParseWebhook(eventType, payload)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "malformed payload: " + err.Error()})
+		return
+	}
+
+	projectPath := eventProjectPath(event)
+	if projectPath == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "malformed payload: missing project path"})
+		return
+	}
+
+	if !h.discovery.InScannedGroup(projectPath) {
+		c.JSON(http.StatusOK, gin.H{"status": "ignored", "project": projectPath})
+		return
+	}
+
+	if h.refresher.Enqueue(projectPath) {
+		log.Printf("INFO: %s received for %s, refresh queued.", eventType, projectPath)
+	} else {
+		log.Printf("INFO: %s received for %s, merged into the pending refresh.", eventType, projectPath)
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"status": "accepted", "project": projectPath})
+}
+
+// eventProjectPath extracts the path of the project an event refers to.
+func eventProjectPath(event any) string {
+	switch e := event.(type) {
+	case *gitlab.PushEvent:
+		return e.Project.PathWithNamespace
+	case *gitlab.TagEvent:
+		return e.Project.PathWithNamespace
+	case *gitlab.PipelineEvent:
+		return e.Project.PathWithNamespace
+	case *gitlab.DeploymentEvent:
+		return e.Project.PathWithNamespace
+	}
+	return ""
+}
@@ -1,19 +1,51 @@
 package routes
 
 import (
+	"dev-compass/internal/infrastructure/http/handlers/apis"
 	"dev-compass/internal/infrastructure/http/handlers/catalog"
+	"dev-compass/internal/infrastructure/http/handlers/discovery"
 	"dev-compass/internal/infrastructure/http/handlers/environments"
+	"dev-compass/internal/infrastructure/http/handlers/groups"
+	"dev-compass/internal/infrastructure/http/handlers/locations"
+	"dev-compass/internal/infrastructure/http/handlers/schemas"
+	"dev-compass/internal/infrastructure/http/handlers/systems"
 	"dev-compass/internal/infrastructure/http/handlers/techdocs"
+	"dev-compass/internal/infrastructure/http/handlers/webhooks"
 	"github.com/gin-gonic/gin"
 )
 
 // SetupRoutes configures the application's HTTP routes.
-func SetupRoutes(router *gin.Engine, catalogHandler *catalog.Handler, techdocsHandler *techdocs.Handler, environmentHandler *environments.Handler) {
+func SetupRoutes(router *gin.Engine, catalogHandler *catalog.Handler, techdocsHandler *techdocs.Handler, environmentHandler *environments.Handler, discoveryHandler *discovery.Handler, webhooksHandler *webhooks.Handler, locationsHandler *locations.Handler, apisHandler *apis.Handler, systemsHandler *systems.Handler, groupsHandler *groups.Handler, schemasHandler *schemas.Handler) {
 	api := router.Group("/api/v1")
 	{
 		api.GET("/entities", catalogHandler.GetAllEntities)
+		api.GET("/entities/facets", catalogHandler.GetFacets)
+		api.GET("/entities/:kind/:namespace/:name", catalogHandler.GetEntity)
+		api.PUT("/entities/:kind/:namespace/:name", catalogHandler.PutEntity)
+		api.DELETE("/entities/:kind/:namespace/:name", catalogHandler.DeleteEntity)
 		api.GET("/entities/:kind/:namespace/:name/docs/*path", techdocsHandler.GetDoc) // Corrected method name
+		api.GET("/entities/:kind/:namespace/:name/environments", environmentHandler.GetEnvironmentsByEntity)
+		api.GET("/entities/:kind/:namespace/:name/relations", catalogHandler.GetRelations)
+		api.GET("/entities/:kind/:namespace/:name/history", catalogHandler.GetHistory)
+		api.GET("/entities/:kind/:namespace/:name/history/:revision", catalogHandler.GetRevision)
+		api.GET("/entities/:kind/:namespace/:name/diff", catalogHandler.DiffRevisions)
+		api.GET("/search", catalogHandler.Search)
+		api.GET("/apis/:namespace/:name", apisHandler.GetAPI)
+		api.GET("/apis/:namespace/:name/definition", apisHandler.GetDefinition)
+		api.GET("/systems/:namespace", systemsHandler.GetDefaultNamespaceSystem) // /systems/:name, see the handler
+		api.GET("/systems/:namespace/:name", systemsHandler.GetSystem)
+		api.GET("/groups/:namespace/owned", groupsHandler.GetDefaultNamespaceOwnedEntities) // /groups/:name/owned, see the handler
+		api.GET("/groups/:namespace/:name/owned", groupsHandler.GetOwnedEntities)
 		api.GET("/environments", environmentHandler.GetEnvironments)
-		api.GET("/components/:componentName/environments", environmentHandler.GetEnvironmentsByComponent)
+		api.GET("/components/:componentName/environments", environmentHandler.GetEnvironmentsByComponent) // Kept for clients that only know the name
+		api.GET("/discovery/status", discoveryHandler.GetStatus)
+		api.POST("/discovery/run", discoveryHandler.TriggerRun)
+		api.POST("/webhooks/gitlab", webhooksHandler.HandleGitLab)
+		api.GET("/locations", locationsHandler.GetAllLocations)
+		api.POST("/locations", locationsHandler.RegisterLocation)
+		api.GET("/locations/:id", locationsHandler.GetLocation)
+		api.DELETE("/locations/:id", locationsHandler.UnregisterLocation)
+		api.GET("/schemas", schemasHandler.GetAllSchemas)
+		api.GET("/schemas/:version/:file", schemasHandler.GetSchema)
 	}
 }
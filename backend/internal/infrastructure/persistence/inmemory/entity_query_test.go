@@ -0,0 +1,185 @@
+package inmemory
+
+import (
+	"dev-compass/internal/domain/entities"
+	"dev-compass/internal/domain/ports"
+	"dev-compass/internal/infrastructure/config"
+	"dev-compass/internal/infrastructure/persistence/sqlite"
+	"gorm.io/datatypes"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// queryFixtures returns entities covering what queries compare: case, bytes
+// ordering, missing and non-text spec fields, duplicated tags, labels with dots.
+func queryFixtures() []*entities.Entity {
+	entity := func(kind, namespace, name, description, spec, tags string, labels, annotations datatypes.JSONMap) *entities.Entity {
+		return &entities.Entity{
+			Kind: kind,
+			Metadata: entities.Metadata{
+				UID:         kind + "-" + namespace + "-" + name,
+				Name:        name,
+				Namespace:   namespace,
+				Description: description,
+				Tags:        datatypes.JSON(tags),
+				Labels:      labels,
+				Annotations: annotations,
+			},
+			Spec: datatypes.JSON(spec),
+		}
+	}
+	return []*entities.Entity{
+		entity("Component", "default", "alpha", "Payments API", `{"owner":"team-a","lifecycle":"production","type":"service","replicas":3}`, `["go","web"]`,
+			datatypes.JSONMap{"tier": "web", "app.kubernetes.io/part-of": "shop"}, datatypes.JSONMap{"backstage.io/techdocs-ref": "dir:."}),
+		entity("Component", "default", "Zeta", "Checkout", `{"owner":"Team-A","lifecycle":"experimental","type":"website"}`, `["web","web"]`,
+			datatypes.JSONMap{"tier": "web"}, nil),
+		entity("Component", "payments", "beta", "Ledger alpha", `{"owner":"team-b","type":"service","replicas":1}`, `["go"]`,
+			datatypes.JSONMap{"tier": "db"}, nil),
+		entity("API", "default", "orders-api", "", `{"owner":"team-b","lifecycle":"production","type":"openapi"}`, `[]`,
+			nil, datatypes.JSONMap{"backstage.io/techdocs-ref": "dir:."}),
+		entity("System", "default", "shop", "The shop", `{"owner":"team-a"}`, `null`,
+			datatypes.JSONMap{"app.kubernetes.io/part-of": "shop"}, nil),
+		entity("Group", "default", "team-a", "", `{"type":"team"}`, `["people"]`, nil, nil),
+		entity("Component", "default", "_internal", "", `{"owner":"team-c","lifecycle":"deprecated","type":"library"}`, `["go"]`,
+			datatypes.JSONMap{"tier": ""}, nil),
+	}
+}
+
+// newParityRepositories returns an in-memory and a SQLite repository holding the query fixtures.
+func newParityRepositories(t *testing.T) (*EntityRepository, *sqlite.EntityRepository) {
+	t.Helper()
+	memory, err := NewEntityRepository("", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn, err := sqlite.ConnectDB(&config.Config{DB: &config.DB{Driver: config.DriverSQLite, Path: filepath.Join(t.TempDir(), "catalog.db")}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := conn.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	if err := sqlite.Migrate(conn); err != nil {
+		t.Fatal(err)
+	}
+	database := sqlite.NewEntityRepository(conn, 0)
+
+	for _, repo := range []ports.EntityRepository{memory, database} {
+		for _, entity := range queryFixtures() {
+			if err := repo.Save(entity); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	return memory, database
+}
+
+// queryAllPages returns the refs of every page of a query, following the cursors.
+func queryAllPages(t *testing.T, repo ports.EntityRepository, query ports.EntityQuery) ([]string, int64) {
+	t.Helper()
+	var refs []string
+	var total int64
+	for pages := 0; ; pages++ {
+		if pages > 10 {
+			t.Fatalf("query %+v does not end", query)
+		}
+		page, err := repo.Query(query)
+		if err != nil {
+			t.Fatalf("Query(%+v) error = %v", query, err)
+		}
+		total = page.TotalCount
+		for _, entity := range page.Entities {
+			refs = append(refs, entity.Ref)
+		}
+		if page.NextCursor == "" {
+			return refs, total
+		}
+		query.Cursor = page.NextCursor
+	}
+}
+
+func TestQueryMatchesSQLite(t *testing.T) {
+	memory, database := newParityRepositories(t)
+	selector := func(s string) []ports.LabelRequirement {
+		requirements, err := ports.ParseLabelSelector(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return requirements
+	}
+	tests := []struct {
+		name  string
+		query ports.EntityQuery
+	}{
+		{"everything", ports.EntityQuery{}},
+		{"pages by name", ports.EntityQuery{Limit: 2}},
+		{"pages by name descending", ports.EntityQuery{Descending: true, Limit: 3}},
+		{"pages by owner", ports.EntityQuery{Sort: ports.SortByOwner, Limit: 2}},
+		{"pages by lifecycle descending", ports.EntityQuery{Sort: ports.SortByLifecycle, Descending: true, Limit: 2}},
+		{"pages by type", ports.EntityQuery{Sort: ports.SortByType, Limit: 1}},
+		{"pages by namespace", ports.EntityQuery{Sort: ports.SortByNamespace, Limit: 4}},
+		{"pages by kind", ports.EntityQuery{Sort: ports.SortByKind, Limit: 3}},
+		{"search", ports.EntityQuery{Search: "ALPHA"}},
+		{"search wildcard", ports.EntityQuery{Search: "_"}},
+		{"kinds and owners ignore case", ports.EntityQuery{Kinds: []string{"component"}, Owners: []string{"TEAM-A"}}},
+		{"lifecycles and types", ports.EntityQuery{Lifecycles: []string{"production", "experimental"}, Types: []string{"SERVICE", "website"}}},
+		{"every tag", ports.EntityQuery{Tags: []string{"go", "web"}}},
+		{"label in", ports.EntityQuery{LabelSelector: selector("tier in (web,db)")}},
+		{"label not equal", ports.EntityQuery{LabelSelector: selector("tier!=web")}},
+		{"label exists", ports.EntityQuery{LabelSelector: selector("app.kubernetes.io/part-of")}},
+		{"label does not exist", ports.EntityQuery{LabelSelector: selector("!tier")}},
+		{"empty label value", ports.EntityQuery{LabelSelector: selector("tier=")}},
+		{"annotations", ports.EntityQuery{Annotations: []string{"backstage.io/techdocs-ref"}}},
+		{"combined", ports.EntityQuery{Kinds: []string{"Component"}, Tags: []string{"go"}, Sort: ports.SortByOwner, Descending: true, Limit: 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wantRefs, wantTotal := queryAllPages(t, database, tt.query)
+			gotRefs, gotTotal := queryAllPages(t, memory, tt.query)
+			if !reflect.DeepEqual(gotRefs, wantRefs) || gotTotal != wantTotal {
+				t.Errorf("in-memory = %v (%d), SQLite = %v (%d)", gotRefs, gotTotal, wantRefs, wantTotal)
+			}
+		})
+	}
+}
+
+func TestFacetsMatchSQLite(t *testing.T) {
+	memory, database := newParityRepositories(t)
+	var facets []ports.Facet
+	for _, name := range []string{"kind", "metadata.namespace", "tags", "metadata.labels.tier", "metadata.labels.app.kubernetes.io/part-of", "spec.owner", "spec.lifecycle", "spec.replicas", "spec.missing"} {
+		facet, err := ports.ParseFacet(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		facets = append(facets, facet)
+	}
+	tests := []struct {
+		name  string
+		query ports.EntityQuery
+	}{
+		{"everything", ports.EntityQuery{}},
+		{"filtered on a faceted field", ports.EntityQuery{Kinds: []string{"component"}, Tags: []string{"web"}}},
+		{"filtered on labels", ports.EntityQuery{LabelSelector: []ports.LabelRequirement{{Key: "tier", Operator: ports.LabelEquals, Values: []string{"web"}}}, Search: "a"}},
+		{"nothing matches", ports.EntityQuery{Owners: []string{"nobody"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want, err := database.Facets(tt.query, facets)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := memory.Facets(tt.query, facets)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, facet := range facets {
+				if !reflect.DeepEqual(got[facet.Name], want[facet.Name]) {
+					t.Errorf("%s: in-memory = %v, SQLite = %v", facet.Name, got[facet.Name], want[facet.Name])
+				}
+			}
+		})
+	}
+}
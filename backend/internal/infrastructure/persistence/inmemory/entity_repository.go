@@ -2,80 +2,373 @@ package inmemory
 
 import (
 	"dev-compass/internal/domain/entities"
+	"dev-compass/internal/domain/ports"
 	"encoding/json"
 	"os"
+	"slices"
+	"sort"
 	"strings"
+	"sync"
 )
 
 // EntityRepository is an in-memory implementation of the entity repository.
 type EntityRepository struct {
-	entities []entities.Entity
+	mu           sync.RWMutex
+	entities     []entities.Entity
+	revisions    map[string][]entities.EntityRevision // By reference, oldest first
+	maxRevisions int                                  // Revisions kept per entity; 0 keeps them all
 }
 
-// NewEntityRepository creates a new in-memory entity repository.
-func NewEntityRepository(mockFilePath string) (*EntityRepository, error) {
+// NewEntityRepository creates a new in-memory entity repository that keeps the
+// maxRevisions newest revisions of every entity, or all of them when it is 0.
+func NewEntityRepository(mockFilePath string, maxRevisions int) (*EntityRepository, error) {
 	if mockFilePath == "" {
-		return &EntityRepository{entities: make([]entities.Entity, 0)}, nil
+		return &EntityRepository{entities: make([]entities.Entity, 0), revisions: make(map[string][]entities.EntityRevision), maxRevisions: maxRevisions}, nil
 	}
 	data, err := os.ReadFile(mockFilePath)
 	if err != nil {
 		return nil, err
 	}
 
-	var entities []entities.Entity
-	if err := json.Unmarshal(data, &entities); err != nil {
+	var loaded []entities.Entity
+	if err := json.Unmarshal(data, &loaded); err != nil {
 		return nil, err
 	}
+	for i := range loaded {
+		loaded[i].Ref = loaded[i].CanonicalRef()
+	}
 
-	return &EntityRepository{entities: entities}, nil
+	return &EntityRepository{entities: loaded, revisions: make(map[string][]entities.EntityRevision), maxRevisions: maxRevisions}, nil
 }
 
-// FindAll returns all entities from the in-memory store, with optional filtering.
-func (r *EntityRepository) FindAll(search, tag string) ([]entities.Entity, error) {
-	var filtered []entities.Entity
+// FindAll returns all entities from the in-memory store.
+func (r *EntityRepository) FindAll() ([]entities.Entity, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return append([]entities.Entity(nil), r.entities...), nil
+}
 
-	searchLower := strings.ToLower(search)
+// Query returns the page of entities selected by a query. It behaves as the
+// Postgres repository does, so both can be used interchangeably.
+func (r *EntityRepository) Query(query ports.EntityQuery) (*ports.EntityPage, error) {
+	sortField, err := query.SortField()
+	if err != nil {
+		return nil, err
+	}
+	cursor, err := ports.DecodeCursor(query.Cursor, sortField, query.Descending)
+	if err != nil {
+		return nil, err
+	}
 
+	r.mu.RLock()
+	var matched []entities.Entity
 	for _, e := range r.entities {
-		// Tag filtering
-		if tag != "" {
-			foundTag := false
-			var tags []string
-			if err := json.Unmarshal(e.Metadata.Tags, &tags); err == nil {
-				for _, t := range tags {
-					if t == tag {
-						foundTag = true
-						break
-					}
-				}
+		if matchesQuery(e, query) {
+			matched = append(matched, e)
+		}
+	}
+	r.mu.RUnlock()
+
+	// Entities are ordered by sort value, then by reference, comparing bytes as Postgres does with the C collation.
+	type position struct{ value, ref string }
+	positionOf := func(e entities.Entity) position { return position{sortValue(e, sortField), e.Ref} }
+	before := func(a, b position) bool {
+		if query.Descending {
+			a, b = b, a
+		}
+		if a.value != b.value {
+			return a.value < b.value
+		}
+		return a.ref < b.ref
+	}
+	sort.SliceStable(matched, func(i, j int) bool { return before(positionOf(matched[i]), positionOf(matched[j])) })
+
+	page := &ports.EntityPage{Entities: []entities.Entity{}, TotalCount: int64(len(matched))}
+	start := 0
+	if cursor != nil {
+		last := position{cursor.Value, cursor.Ref}
+		start = sort.Search(len(matched), func(i int) bool { return before(last, positionOf(matched[i])) })
+	}
+	end := len(matched)
+	if query.Limit > 0 && start+query.Limit < end {
+		end = start + query.Limit
+		last := matched[end-1]
+		page.NextCursor = ports.PageCursor{Sort: sortField, Descending: query.Descending, Value: sortValue(last, sortField), Ref: last.Ref}.Encode()
+	}
+	page.Entities = append(page.Entities, matched[start:end]...)
+	return page, nil
+}
+
+// Facets counts the distinct values of each facet across the entities matching a query.
+func (r *EntityRepository) Facets(query ports.EntityQuery, facets []ports.Facet) (map[string][]ports.FacetCount, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	result := make(map[string][]ports.FacetCount, len(facets))
+	for _, facet := range facets {
+		facetQuery := query.WithoutFilterOn(facet)
+		counts := make(map[string]int64)
+		for _, e := range r.entities {
+			if !matchesQuery(e, facetQuery) {
+				continue
 			}
-			if !foundTag {
-				continue // Skip if tag doesn't match
+			for _, value := range facetValues(e, facet) {
+				if value != "" {
+					counts[value]++
+				}
 			}
 		}
 
-		// Search filtering
-		if searchLower != "" {
-			if !strings.Contains(strings.ToLower(e.Metadata.Name), searchLower) &&
-				!strings.Contains(strings.ToLower(e.Metadata.Description), searchLower) {
-				continue // Skip if search doesn't match name or description
+		list := make([]ports.FacetCount, 0, len(counts))
+		for value, count := range counts {
+			list = append(list, ports.FacetCount{Value: value, Count: count})
+		}
+		ports.SortFacetCounts(list)
+		result[facet.Name] = list
+	}
+	return result, nil
+}
+
+// facetValues returns the distinct values an entity has for a facet.
+func facetValues(e entities.Entity, facet ports.Facet) []string {
+	switch facet.Field {
+	case ports.FacetKind:
+		return []string{e.Kind}
+	case ports.FacetNamespace:
+		return []string{e.Metadata.Namespace}
+	case ports.FacetTags:
+		var tags []string
+		_ = json.Unmarshal(e.Metadata.Tags, &tags)
+		slices.Sort(tags)
+		return slices.Compact(tags)
+	case ports.FacetLabel:
+		value, _ := e.Metadata.Labels[facet.Key].(string)
+		return []string{value}
+	case ports.FacetSpec:
+		return []string{specText(e, facet.Key)}
+	default:
+		return nil
+	}
+}
+
+// matchesQuery reports whether an entity passes every filter of a query.
+func matchesQuery(e entities.Entity, query ports.EntityQuery) bool {
+	if search := strings.ToLower(query.Search); search != "" &&
+		!strings.Contains(strings.ToLower(e.Metadata.Name), search) &&
+		!strings.Contains(strings.ToLower(e.Metadata.Description), search) {
+		return false
+	}
+	if !matchesAny(query.Kinds, e.Kind) ||
+		!matchesAny(query.Owners, specText(e, "owner")) ||
+		!matchesAny(query.Lifecycles, specText(e, "lifecycle")) ||
+		!matchesAny(query.Types, specText(e, "type")) {
+		return false
+	}
+
+	if len(query.Tags) > 0 {
+		var tags []string
+		_ = json.Unmarshal(e.Metadata.Tags, &tags)
+		for _, tag := range query.Tags {
+			if !slices.Contains(tags, tag) {
+				return false
 			}
 		}
+	}
+	for _, requirement := range query.LabelSelector {
+		if !requirement.Matches(e.Metadata.Labels) {
+			return false
+		}
+	}
+	for _, key := range query.Annotations {
+		if _, ok := e.Metadata.Annotations[key]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// matchesAny reports whether value is one of values, ignoring case. No values match everything.
+func matchesAny(values []string, value string) bool {
+	if len(values) == 0 {
+		return true
+	}
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// specText returns a top-level field of the spec of an entity as text, as the
+// Postgres ->> operator does. A missing field is empty.
+func specText(e entities.Entity, field string) string {
+	var spec map[string]json.RawMessage
+	if err := json.Unmarshal(e.Spec, &spec); err != nil {
+		return ""
+	}
+	raw, ok := spec[field]
+	if !ok || string(raw) == "null" {
+		return ""
+	}
+	var text string
+	if err := json.Unmarshal(raw, &text); err != nil {
+		return string(raw)
+	}
+	return text
+}
 
-		filtered = append(filtered, e)
+// sortValue returns the value an entity is sorted by.
+func sortValue(e entities.Entity, field string) string {
+	switch field {
+	case ports.SortByNamespace:
+		return e.Metadata.Namespace
+	case ports.SortByKind:
+		return e.Kind
+	case ports.SortByOwner, ports.SortByLifecycle, ports.SortByType:
+		return specText(e, field)
+	default:
+		return e.Metadata.Name
 	}
+}
+
+// FindByRef returns a single entity by its canonical reference.
+func (r *EntityRepository) FindByRef(ref string) (*entities.Entity, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
 
-	return filtered, nil
+	ref = strings.ToLower(ref)
+	for _, e := range r.entities {
+		if e.Ref == ref {
+			return &e, nil
+		}
+	}
+	return nil, ports.ErrNotFound
 }
 
-// Save adds an entity to the in-memory store.
+// Save adds an entity to the in-memory store, replacing any entity with the same
+// reference, and records a revision when its content changed.
 func (r *EntityRepository) Save(entity *entities.Entity) error {
-	r.entities = append(r.entities, *entity)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entity.Ref = entity.CanonicalRef()
+	replaced := false
+	for i, e := range r.entities {
+		if e.Ref == entity.Ref {
+			if len(r.revisions[entity.Ref]) == 0 {
+				// Entities loaded from the mock file start their history with the stored version.
+				if err := r.appendRevision(&e); err != nil {
+					return err
+				}
+			}
+			r.entities[i] = *entity
+			replaced = true
+			break
+		}
+	}
+	if !replaced {
+		r.entities = append(r.entities, *entity)
+	}
+	return r.appendRevision(entity)
+}
+
+// UpdateStatus replaces the status and etag of an entity, provided its etag is still etag.
+func (r *EntityRepository) UpdateStatus(ref string, status *entities.EntityStatus, etag, newETag string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ref = strings.ToLower(ref)
+	for i := range r.entities {
+		if r.entities[i].Ref != ref {
+			continue
+		}
+		if r.entities[i].Metadata.ETag != etag {
+			return ports.ErrConflict
+		}
+		r.entities[i].Status = status
+		r.entities[i].Metadata.ETag = newETag
+		return nil
+	}
+	return ports.ErrNotFound
+}
+
+// appendRevision records the next revision of an entity, unless its content is
+// the same as in the latest one, and prunes the oldest ones. The caller must hold the lock.
+func (r *EntityRepository) appendRevision(entity *entities.Entity) error {
+	revisions := r.revisions[entity.Ref]
+	var latest *entities.EntityRevision
+	if len(revisions) > 0 {
+		latest = &revisions[len(revisions)-1]
+	}
+	next := int64(1)
+	if latest != nil {
+		next = latest.Revision + 1
+	}
+	revision, err := entities.NewEntityRevision(entity, next)
+	if err != nil {
+		return err
+	}
+	if latest != nil && revision.SameContent(latest) {
+		return nil
+	}
+	revisions = append(revisions, *revision)
+	if r.maxRevisions > 0 && len(revisions) > r.maxRevisions {
+		revisions = append([]entities.EntityRevision(nil), revisions[len(revisions)-r.maxRevisions:]...)
+	}
+	r.revisions[entity.Ref] = revisions
+	return nil
+}
+
+// FindRevisions returns the revisions of an entity, newest first, without their snapshot.
+func (r *EntityRepository) FindRevisions(ref string) ([]entities.EntityRevision, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	stored := r.revisions[strings.ToLower(ref)]
+	revisions := make([]entities.EntityRevision, 0, len(stored))
+	for i := len(stored) - 1; i >= 0; i-- {
+		revision := stored[i]
+		revision.Entity = nil
+		revisions = append(revisions, revision)
+	}
+	return revisions, nil
+}
+
+// FindRevision returns a single revision of an entity, with its snapshot.
+func (r *EntityRepository) FindRevision(ref string, revision int64) (*entities.EntityRevision, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, stored := range r.revisions[strings.ToLower(ref)] {
+		if stored.Revision == revision {
+			return &stored, nil
+		}
+	}
+	return nil, ports.ErrNotFound
+}
+
+// Delete removes a single entity by its canonical reference from the in-memory store.
+func (r *EntityRepository) Delete(ref string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ref = strings.ToLower(ref)
+	for i, e := range r.entities {
+		if e.Ref == ref {
+			r.entities = append(r.entities[:i], r.entities[i+1:]...)
+			return nil
+		}
+	}
 	return nil
 }
 
 // DeleteAll removes all records from the in-memory store.
 func (r *EntityRepository) DeleteAll() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
 	r.entities = make([]entities.Entity, 0)
 	return nil
 }
@@ -0,0 +1,52 @@
+package inmemory
+
+import (
+	"dev-compass/internal/domain/entities"
+	"testing"
+)
+
+func TestSavePrunesOldRevisions(t *testing.T) {
+	tests := []struct {
+		name         string
+		maxRevisions int
+		want         []int64
+	}{
+		{"limited", 3, []int64{5, 4, 3}},
+		{"unlimited", 0, []int64{5, 4, 3, 2, 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, err := NewEntityRepository("", tt.maxRevisions)
+			if err != nil {
+				t.Fatal(err)
+			}
+			entity := &entities.Entity{Kind: "Component", Metadata: entities.Metadata{Name: "orders", Namespace: entities.DefaultNamespace}, Spec: []byte(`{}`)}
+			for _, description := range []string{"one", "two", "two", "three", "four", "five"} {
+				entity.Metadata.Description = description
+				if err := repo.Save(entity); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			revisions, err := repo.FindRevisions(entity.Ref)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []int64
+			for _, revision := range revisions {
+				got = append(got, revision.Revision)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("revisions = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("revisions = %v, want %v", got, tt.want)
+				}
+			}
+			if _, err := repo.FindRevision(entity.Ref, tt.want[len(tt.want)-1]); err != nil {
+				t.Errorf("oldest kept revision: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package inmemory
+
+import (
+	"dev-compass/internal/domain/entities"
+	"dev-compass/internal/domain/ports"
+	"sync"
+)
+
+// LocationRepository is an in-memory implementation of the location repository.
+type LocationRepository struct {
+	mu        sync.RWMutex
+	locations []entities.Location
+}
+
+// NewLocationRepository creates a new in-memory location repository.
+func NewLocationRepository() *LocationRepository {
+	return &LocationRepository{locations: make([]entities.Location, 0)}
+}
+
+// FindAll returns all registered locations.
+func (r *LocationRepository) FindAll() ([]entities.Location, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return append([]entities.Location(nil), r.locations...), nil
+}
+
+// FindByID returns a single location by its ID.
+func (r *LocationRepository) FindByID(id string) (*entities.Location, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, l := range r.locations {
+		if l.ID == id {
+			return &l, nil
+		}
+	}
+	return nil, ports.ErrNotFound
+}
+
+// Save adds a location to the in-memory store.
+func (r *LocationRepository) Save(location *entities.Location) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.locations = append(r.locations, *location)
+	return nil
+}
+
+// Delete removes a single location by its ID.
+func (r *LocationRepository) Delete(id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, l := range r.locations {
+		if l.ID == id {
+			r.locations = append(r.locations[:i], r.locations[i+1:]...)
+			return nil
+		}
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package inmemory
+
+import (
+	"dev-compass/internal/domain/entities"
+	"sync"
+)
+
+// RelationRepository is an in-memory implementation of the relation repository.
+type RelationRepository struct {
+	mu        sync.RWMutex
+	relations []entities.EntityRelation
+}
+
+// NewRelationRepository creates a new in-memory relation repository.
+func NewRelationRepository() *RelationRepository {
+	return &RelationRepository{relations: make([]entities.EntityRelation, 0)}
+}
+
+// FindAll returns all stored relations.
+func (r *RelationRepository) FindAll() ([]entities.EntityRelation, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return append([]entities.EntityRelation(nil), r.relations...), nil
+}
+
+// FindBySource returns the relations going out of an entity.
+func (r *RelationRepository) FindBySource(ref string) ([]entities.EntityRelation, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var found []entities.EntityRelation
+	for _, relation := range r.relations {
+		if relation.SourceRef == ref {
+			found = append(found, relation)
+		}
+	}
+	return found, nil
+}
+
+// FindByTarget returns the relations pointing to an entity.
+func (r *RelationRepository) FindByTarget(ref string) ([]entities.EntityRelation, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var found []entities.EntityRelation
+	for _, relation := range r.relations {
+		if relation.TargetRef == ref {
+			found = append(found, relation)
+		}
+	}
+	return found, nil
+}
+
+// ReplaceForOrigin replaces every relation declared by the origin entity.
+func (r *RelationRepository) ReplaceForOrigin(origin string, relations []entities.EntityRelation) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	kept := r.relations[:0]
+	for _, relation := range r.relations {
+		if relation.OriginRef != origin {
+			kept = append(kept, relation)
+		}
+	}
+	r.relations = append(kept, relations...)
+	return nil
+}
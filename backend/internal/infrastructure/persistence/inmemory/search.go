@@ -0,0 +1,130 @@
+package inmemory
+
+import (
+	"dev-compass/internal/domain/entities"
+	"dev-compass/internal/domain/ports"
+	"dev-compass/internal/infrastructure/persistence/textsearch"
+	"encoding/json"
+	"sort"
+	"strings"
+)
+
+// Search weights of the fields of an entity, mirroring the weights of the Postgres search vector.
+var searchWeights = []struct {
+	weight float64
+	text   func(e entities.Entity) string
+}{
+	{1.0, func(e entities.Entity) string { return e.Metadata.Name }},
+	{0.4, func(e entities.Entity) string { return e.Metadata.Description }},
+	{0.4, tagsText},
+	{0.2, annotationValues},
+	{0.1, func(e entities.Entity) string { return specText(e, "readmeContent") }},
+}
+
+// snippetWords is the number of words around the first match kept in a snippet.
+const snippetWords = 10
+
+// Search returns the entities matching every word of the text, or whose name or
+// description is within trigram distance of it, most relevant first.
+func (r *EntityRepository) Search(text string, filters ports.EntityQuery) ([]ports.SearchResult, error) {
+	filters.Search = ""
+	terms := textsearch.Words(text)
+
+	r.mu.RLock()
+	results := []ports.SearchResult{}
+	for _, e := range r.entities {
+		if !matchesQuery(e, filters) {
+			continue
+		}
+
+		rank, matchedAll := 0.0, len(terms) > 0
+		for _, term := range terms {
+			matched := false
+			for _, field := range searchWeights {
+				if containsWord(textsearch.Words(field.text(e)), term) {
+					rank += field.weight
+					matched = true
+				}
+			}
+			matchedAll = matchedAll && matched
+		}
+		if !matchedAll && textsearch.WordSimilarity(text, e.Metadata.Name) < textsearch.WordSimilarityThreshold && textsearch.WordSimilarity(text, e.Metadata.Description) < textsearch.WordSimilarityThreshold {
+			continue
+		}
+		results = append(results, ports.SearchResult{
+			Entity:  e,
+			Rank:    rank + textsearch.Similarity(text, e.Metadata.Name),
+			Snippet: snippet(strings.TrimSpace(e.Metadata.Description+"\n"+specText(e, "readmeContent")), terms),
+		})
+	}
+	r.mu.RUnlock()
+
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Rank != results[j].Rank {
+			return results[i].Rank > results[j].Rank
+		}
+		return results[i].Entity.Ref < results[j].Entity.Ref
+	})
+	if filters.Limit > 0 && len(results) > filters.Limit {
+		results = results[:filters.Limit]
+	}
+	return results, nil
+}
+
+// snippet returns the words around the first match in text, with the matching words wrapped in <mark> tags.
+func snippet(text string, terms []string) string {
+	fields := strings.Fields(text)
+	first := 0
+	for i, field := range fields {
+		if matchesAnyTerm(field, terms) {
+			first = max(i-snippetWords/2, 0)
+			break
+		}
+	}
+	last := min(first+snippetWords*2, len(fields))
+
+	excerpt := make([]string, 0, last-first)
+	for _, field := range fields[first:last] {
+		if matchesAnyTerm(field, terms) {
+			field = "<mark>" + field + "</mark>"
+		}
+		excerpt = append(excerpt, field)
+	}
+	return strings.Join(excerpt, " ")
+}
+
+func matchesAnyTerm(field string, terms []string) bool {
+	for _, word := range textsearch.Words(field) {
+		if containsWord(terms, word) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsWord(list []string, word string) bool {
+	for _, w := range list {
+		if w == word {
+			return true
+		}
+	}
+	return false
+}
+
+// annotationValues returns the values of the annotations of an entity, separated by spaces.
+func annotationValues(e entities.Entity) string {
+	var values []string
+	for _, value := range e.Metadata.Annotations {
+		if s, ok := value.(string); ok {
+			values = append(values, s)
+		}
+	}
+	return strings.Join(values, " ")
+}
+
+// tagsText returns the tags of an entity separated by spaces.
+func tagsText(e entities.Entity) string {
+	var tags []string
+	_ = json.Unmarshal(e.Metadata.Tags, &tags)
+	return strings.Join(tags, " ")
+}
@@ -2,41 +2,354 @@ package postgres
 
 import (
 	"dev-compass/internal/domain/entities"
+	"dev-compass/internal/domain/ports"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"strings"
 )
 
 // EntityRepository is a GORM implementation of the entity repository.
 type EntityRepository struct {
-	db *gorm.DB
+	db           *gorm.DB
+	maxRevisions int // Revisions kept per entity; 0 keeps them all
 }
 
-// NewEntityRepository creates a new GORM entity repository.
-func NewEntityRepository(db *gorm.DB) *EntityRepository {
-	return &EntityRepository{db: db}
+// NewEntityRepository creates a new GORM entity repository that keeps the
+// maxRevisions newest revisions of every entity, or all of them when it is 0.
+func NewEntityRepository(db *gorm.DB, maxRevisions int) *EntityRepository {
+	return &EntityRepository{db: db, maxRevisions: maxRevisions}
 }
 
-// FindAll retrieves all entities, with optional filtering.
-func (r *EntityRepository) FindAll(search, tag string) ([]entities.Entity, error) {
+// FindAll retrieves all entities.
+func (r *EntityRepository) FindAll() ([]entities.Entity, error) {
 	var entityList []entities.Entity
-	tx := r.db.Model(&entities.Entity{})
+	if err := r.db.Find(&entityList).Error; err != nil {
+		return nil, err
+	}
+	return entityList, nil
+}
+
+// sortExpressions maps every sort field to the SQL expression it sorts by.
+var sortExpressions = map[string]string{
+	ports.SortByName:      "COALESCE(metadata_name, '')",
+	ports.SortByNamespace: "COALESCE(metadata_namespace, '')",
+	ports.SortByKind:      "COALESCE(kind, '')",
+	ports.SortByOwner:     "COALESCE(spec->>'owner', '')",
+	ports.SortByLifecycle: "COALESCE(spec->>'lifecycle', '')",
+	ports.SortByType:      "COALESCE(spec->>'type', '')",
+}
 
-	if search != "" {
-		searchTerm := "%" + search + "%"
-		tx = tx.Where(`metadata_name ILIKE ? OR metadata_description ILIKE ?`, searchTerm, searchTerm)
+// Query returns the page of entities selected by a query, filtering on the jsonb
+// columns in the database. Values are compared with the C collation, so the order
+// doesn't depend on the locale of the database.
+func (r *EntityRepository) Query(query ports.EntityQuery) (*ports.EntityPage, error) {
+	sortField, err := query.SortField()
+	if err != nil {
+		return nil, err
 	}
-	if tag != "" {
-		// Implement tag logic for postgres
+	cursor, err := ports.DecodeCursor(query.Cursor, sortField, query.Descending)
+	if err != nil {
+		return nil, err
 	}
+	sortExpression := sortExpressions[sortField]
 
-	if err := tx.Find(&entityList).Error; err != nil {
+	page := &ports.EntityPage{Entities: []entities.Entity{}}
+	if err := filterEntities(r.db.Model(&entities.Entity{}), query).Count(&page.TotalCount).Error; err != nil {
 		return nil, err
 	}
-	return entityList, nil
+
+	direction, comparison := "ASC", ">"
+	if query.Descending {
+		direction, comparison = "DESC", "<"
+	}
+	tx := filterEntities(r.db.Model(&entities.Entity{}), query).
+		Order(fmt.Sprintf(`%s COLLATE "C" %s, ref COLLATE "C" %s`, sortExpression, direction, direction))
+	if cursor != nil {
+		tx = tx.Where(fmt.Sprintf(`(%s COLLATE "C", ref COLLATE "C") %s (?, ?)`, sortExpression, comparison), cursor.Value, cursor.Ref)
+	}
+	if query.Limit > 0 {
+		// One more entity tells whether there is a next page.
+		tx = tx.Limit(query.Limit + 1)
+	}
+	if err := tx.Find(&page.Entities).Error; err != nil {
+		return nil, err
+	}
+
+	if query.Limit > 0 && len(page.Entities) > query.Limit {
+		page.Entities = page.Entities[:query.Limit]
+		last := page.Entities[query.Limit-1]
+		var value string
+		if err := r.db.Model(&entities.Entity{}).Select(sortExpression).Where("ref = ?", last.Ref).Scan(&value).Error; err != nil {
+			return nil, err
+		}
+		page.NextCursor = ports.PageCursor{Sort: sortField, Descending: query.Descending, Value: value, Ref: last.Ref}.Encode()
+	}
+	return page, nil
+}
+
+// Facets counts the distinct values of each facet across the entities matching a
+// query. Each facet is grouped and aggregated into a JSON array by the database.
+func (r *EntityRepository) Facets(query ports.EntityQuery, facets []ports.Facet) (map[string][]ports.FacetCount, error) {
+	result := make(map[string][]ports.FacetCount, len(facets))
+	for _, facet := range facets {
+		tx := filterEntities(r.db.Model(&entities.Entity{}), query.WithoutFilterOn(facet))
+		switch facet.Field {
+		case ports.FacetKind:
+			tx = tx.Select("kind AS value, count(*) AS count").Group("kind")
+		case ports.FacetNamespace:
+			tx = tx.Select("metadata_namespace AS value, count(*) AS count").Group("metadata_namespace")
+		case ports.FacetTags:
+			tx = tx.Joins(`CROSS JOIN LATERAL jsonb_array_elements_text(CASE WHEN jsonb_typeof(metadata_tags) = 'array' THEN metadata_tags ELSE '[]'::jsonb END) AS tag(value)`).
+				Select("tag.value AS value, count(DISTINCT ref) AS count").Group("tag.value")
+		case ports.FacetLabel:
+			tx = tx.Select("metadata_labels->>?::text AS value, count(*) AS count", facet.Key).Group("value")
+		case ports.FacetSpec:
+			tx = tx.Select("spec->>?::text AS value, count(*) AS count", facet.Key).Group("value")
+		default:
+			return nil, fmt.Errorf("%w: unknown facet %q", ports.ErrInvalidQuery, facet.Name)
+		}
+
+		var data []byte
+		err := r.db.Raw(`SELECT COALESCE(jsonb_agg(jsonb_build_object('value', value, 'count', count) ORDER BY count DESC, value COLLATE "C"), '[]'::jsonb)
+			FROM (?) AS facet WHERE value IS NOT NULL AND value <> ''`, tx).Row().Scan(&data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to count facet %s: %w", facet.Name, err)
+		}
+		var counts []ports.FacetCount
+		if err := json.Unmarshal(data, &counts); err != nil {
+			return nil, fmt.Errorf("failed to read facet %s: %w", facet.Name, err)
+		}
+		result[facet.Name] = counts
+	}
+	return result, nil
+}
+
+// headlineOptions configure the snippets of search results.
+const headlineOptions = "StartSel=<mark>, StopSel=</mark>, MaxWords=30, MinWords=10, MaxFragments=2"
+
+// Search returns the entities matching a full-text search, ranked by how well the
+// search vector matches and how close the name is to the text. Names and descriptions
+// within trigram distance of the text match too, which makes typos forgivable.
+func (r *EntityRepository) Search(text string, filters ports.EntityQuery) ([]ports.SearchResult, error) {
+	filters.Search = ""
+	ranked := filterEntities(r.db.Model(&entities.Entity{}), filters).
+		Select(`ref, ts_rank(search_vector, websearch_to_tsquery('simple', ?)) + similarity(metadata_name, ?) AS rank`, text, text).
+		Where(`(search_vector @@ websearch_to_tsquery('simple', ?) OR ? <% metadata_name OR ? <% metadata_description)`, text, text, text).
+		Order("rank DESC, ref")
+	if filters.Limit > 0 {
+		ranked = ranked.Limit(filters.Limit)
+	}
+
+	// Snippets are only built for the ranked page, as ts_headline is expensive.
+	var hits []struct {
+		Ref     string
+		Rank    float64
+		Snippet string
+	}
+	err := r.db.Raw(`SELECT ranked.ref, ranked.rank,
+			ts_headline('simple', concat_ws(E'\n', e.metadata_description, e.spec->>'readmeContent'), websearch_to_tsquery('simple', ?), ?) AS snippet
+		FROM (?) AS ranked JOIN entities e ON e.ref = ranked.ref
+		ORDER BY ranked.rank DESC, ranked.ref`, text, headlineOptions, ranked).Scan(&hits).Error
+	if err != nil {
+		return nil, err
+	}
+
+	refs := make([]string, len(hits))
+	for i, hit := range hits {
+		refs[i] = hit.Ref
+	}
+	var found []entities.Entity
+	if len(refs) > 0 {
+		if err := r.db.Where("ref IN ?", refs).Find(&found).Error; err != nil {
+			return nil, err
+		}
+	}
+	byRef := make(map[string]entities.Entity, len(found))
+	for _, entity := range found {
+		byRef[entity.Ref] = entity
+	}
+
+	results := make([]ports.SearchResult, 0, len(hits))
+	for _, hit := range hits {
+		if entity, ok := byRef[hit.Ref]; ok {
+			results = append(results, ports.SearchResult{Entity: entity, Rank: hit.Rank, Snippet: hit.Snippet})
+		}
+	}
+	return results, nil
+}
+
+// filterEntities adds the filters of a query to tx.
+func filterEntities(tx *gorm.DB, query ports.EntityQuery) *gorm.DB {
+	if query.Search != "" {
+		searchTerm := "%" + likeEscaper.Replace(query.Search) + "%"
+		tx = tx.Where(`(metadata_name ILIKE ? OR metadata_description ILIKE ?)`, searchTerm, searchTerm)
+	}
+	if len(query.Kinds) > 0 {
+		tx = tx.Where("lower(kind) IN ?", lowerAll(query.Kinds))
+	}
+	if len(query.Owners) > 0 {
+		tx = tx.Where("lower(spec->>'owner') IN ?", lowerAll(query.Owners))
+	}
+	if len(query.Lifecycles) > 0 {
+		tx = tx.Where("lower(spec->>'lifecycle') IN ?", lowerAll(query.Lifecycles))
+	}
+	if len(query.Types) > 0 {
+		tx = tx.Where("lower(spec->>'type') IN ?", lowerAll(query.Types))
+	}
+	if len(query.Tags) > 0 {
+		tags, _ := json.Marshal(query.Tags)
+		tx = tx.Where("metadata_tags @> ?::jsonb", string(tags))
+	}
+	for _, requirement := range query.LabelSelector {
+		switch requirement.Operator {
+		case ports.LabelEquals, ports.LabelIn:
+			tx = tx.Where("metadata_labels->>?::text IN ?", requirement.Key, requirement.Values)
+		case ports.LabelNotEquals, ports.LabelNotIn:
+			tx = tx.Where("(metadata_labels->>?::text IS NULL OR metadata_labels->>?::text NOT IN ?)", requirement.Key, requirement.Key, requirement.Values)
+		case ports.LabelExists:
+			tx = tx.Where("metadata_labels->>?::text IS NOT NULL", requirement.Key)
+		case ports.LabelDoesNotExist:
+			tx = tx.Where("metadata_labels->>?::text IS NULL", requirement.Key)
+		}
+	}
+	for _, key := range query.Annotations {
+		tx = tx.Where("metadata_annotations->?::text IS NOT NULL", key)
+	}
+	return tx
 }
 
-// Save creates or updates an entity in the database.
+// likeEscaper escapes the wildcards of a LIKE pattern, so searches match them literally.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+func lowerAll(values []string) []string {
+	lowered := make([]string, len(values))
+	for i, value := range values {
+		lowered[i] = strings.ToLower(value)
+	}
+	return lowered
+}
+
+// FindByRef retrieves a single entity by its canonical reference.
+func (r *EntityRepository) FindByRef(ref string) (*entities.Entity, error) {
+	var entity entities.Entity
+	if err := r.db.First(&entity, "ref = ?", strings.ToLower(ref)).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ports.ErrNotFound
+		}
+		return nil, err
+	}
+	return &entity, nil
+}
+
+// Save creates or updates an entity in the database, recording a revision in the
+// same transaction when its content changed. The entity row stays locked until the
+// transaction ends, so concurrent saves of an entity number their revisions in turn.
 func (r *EntityRepository) Save(entity *entities.Entity) error {
-	return r.db.Create(entity).Error
+	entity.Ref = entity.CanonicalRef()
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var stored entities.Entity
+		locked := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("ref = ?", entity.Ref).Limit(1).Find(&stored)
+		if locked.Error != nil {
+			return locked.Error
+		}
+		// The upsert locks the row when it is created, where there was nothing to
+		// lock above, and waits for any other transaction creating it.
+		if err := tx.Clauses(clause.OnConflict{UpdateAll: true}).Create(entity).Error; err != nil {
+			return err
+		}
+
+		// With the row locked, the latest revision can no longer change under us.
+		var latest entities.EntityRevision
+		if err := tx.Where("ref = ?", entity.Ref).Order("revision DESC").Limit(1).Find(&latest).Error; err != nil {
+			return err
+		}
+		if latest.Revision == 0 && locked.RowsAffected > 0 {
+			// Entities stored before revisions were recorded start their history with
+			// the stored version, so the first change can be diffed.
+			first, err := entities.NewEntityRevision(&stored, 1)
+			if err != nil {
+				return err
+			}
+			if err := tx.Create(first).Error; err != nil {
+				return err
+			}
+			latest = *first
+		}
+
+		revision, err := entities.NewEntityRevision(entity, latest.Revision+1)
+		if err != nil {
+			return err
+		}
+		if latest.Revision > 0 && revision.SameContent(&latest) {
+			return nil
+		}
+		if err := tx.Create(revision).Error; err != nil {
+			return err
+		}
+		return r.pruneRevisions(tx, revision)
+	})
+}
+
+// pruneRevisions deletes the revisions of an entity older than the ones kept, latest being the newest.
+func (r *EntityRepository) pruneRevisions(tx *gorm.DB, latest *entities.EntityRevision) error {
+	if r.maxRevisions <= 0 || latest.Revision <= int64(r.maxRevisions) {
+		return nil
+	}
+	return tx.Where("ref = ? AND revision <= ?", latest.Ref, latest.Revision-int64(r.maxRevisions)).Delete(&entities.EntityRevision{}).Error
+}
+
+// UpdateStatus replaces the status and etag of an entity, provided its etag is still etag.
+func (r *EntityRepository) UpdateStatus(ref string, status *entities.EntityStatus, etag, newETag string) error {
+	var statusJSON interface{}
+	if status != nil {
+		data, err := json.Marshal(status)
+		if err != nil {
+			return err
+		}
+		statusJSON = string(data)
+	}
+	result := r.db.Model(&entities.Entity{}).
+		Where("ref = ? AND metadata_e_tag = ?", ref, etag).
+		Updates(map[string]interface{}{"status": statusJSON, "metadata_e_tag": newETag})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected > 0 {
+		return nil
+	}
+	if _, err := r.FindByRef(ref); err != nil {
+		return err
+	}
+	return ports.ErrConflict
+}
+
+// FindRevisions returns the revisions of an entity, newest first, without their snapshot.
+func (r *EntityRepository) FindRevisions(ref string) ([]entities.EntityRevision, error) {
+	revisions := []entities.EntityRevision{}
+	if err := r.db.Omit("entity").Where("ref = ?", strings.ToLower(ref)).Order("revision DESC").Find(&revisions).Error; err != nil {
+		return nil, err
+	}
+	return revisions, nil
+}
+
+// FindRevision returns a single revision of an entity, with its snapshot.
+func (r *EntityRepository) FindRevision(ref string, revision int64) (*entities.EntityRevision, error) {
+	var found entities.EntityRevision
+	if err := r.db.First(&found, "ref = ? AND revision = ?", strings.ToLower(ref), revision).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ports.ErrNotFound
+		}
+		return nil, err
+	}
+	return &found, nil
+}
+
+// Delete removes a single entity by its canonical reference.
+func (r *EntityRepository) Delete(ref string) error {
+	return r.db.Where("ref = ?", strings.ToLower(ref)).Delete(&entities.Entity{}).Error
 }
 
 // DeleteAll removes all records from the entities table.
@@ -0,0 +1,103 @@
+package postgres
+
+import (
+	"fmt"
+	"gorm.io/gorm"
+	"log"
+)
+
+// MigrateEntityRefs moves an existing entities table from the name-only primary
+// key to the canonical "kind:namespace/name" reference. AutoMigrate never
+// changes an existing primary key, so this must run before it. It is a no-op
+// for new databases and for tables that were already migrated.
+func MigrateEntityRefs(db *gorm.DB) error {
+	if !db.Migrator().HasTable("entities") || db.Migrator().HasColumn("entities", "ref") {
+		return nil
+	}
+
+	log.Println("INFO: Migrating entities to namespaced references...")
+	return db.Transaction(func(tx *gorm.DB) error {
+		statements := []string{
+			`ALTER TABLE entities ADD COLUMN IF NOT EXISTS metadata_namespace text DEFAULT 'default'`,
+			`UPDATE entities SET metadata_namespace = 'default' WHERE metadata_namespace IS NULL OR metadata_namespace = ''`,
+			`ALTER TABLE entities ADD COLUMN ref text`,
+			`UPDATE entities SET ref = lower(kind || ':' || metadata_namespace || '/' || metadata_name)`,
+		}
+		for _, statement := range statements {
+			if err := tx.Exec(statement).Error; err != nil {
+				return fmt.Errorf("failed to migrate entities: %w", err)
+			}
+		}
+
+		var constraint string
+		err := tx.Raw(`SELECT conname FROM pg_constraint WHERE conrelid = 'entities'::regclass AND contype = 'p'`).Scan(&constraint).Error
+		if err != nil {
+			return fmt.Errorf("failed to read the entities primary key: %w", err)
+		}
+		if constraint != "" {
+			if err := tx.Exec(fmt.Sprintf(`ALTER TABLE entities DROP CONSTRAINT %q`, constraint)).Error; err != nil {
+				return fmt.Errorf("failed to drop the old primary key: %w", err)
+			}
+		}
+		if err := tx.Exec(`ALTER TABLE entities ADD PRIMARY KEY (ref)`).Error; err != nil {
+			return fmt.Errorf("failed to add the ref primary key: %w", err)
+		}
+		return nil
+	})
+}
+
+// MigrateEntityUIDs gives every existing entity a UID, generation and etag before
+// AutoMigrate adds the unique index on UIDs, which would fail on empty values.
+// It is a no-op for new databases and for tables that were already migrated.
+func MigrateEntityUIDs(db *gorm.DB) error {
+	if !db.Migrator().HasTable("entities") || db.Migrator().HasColumn("entities", "metadata_uid") {
+		return nil
+	}
+
+	log.Println("INFO: Assigning UIDs to existing entities...")
+	return db.Transaction(func(tx *gorm.DB) error {
+		statements := []string{
+			`ALTER TABLE entities ADD COLUMN metadata_uid text`,
+			`ALTER TABLE entities ADD COLUMN IF NOT EXISTS metadata_generation bigint`,
+			`ALTER TABLE entities ADD COLUMN IF NOT EXISTS metadata_e_tag text`,
+			// md5 of random data cast to uuid works on every supported PostgreSQL version, unlike gen_random_uuid.
+			`UPDATE entities SET metadata_uid = md5(random()::text || clock_timestamp()::text || ref)::uuid::text,
+				metadata_generation = 1,
+				metadata_e_tag = substr(md5(random()::text || ref), 1, 16)`,
+		}
+		for _, statement := range statements {
+			if err := tx.Exec(statement).Error; err != nil {
+				return fmt.Errorf("failed to assign entity UIDs: %w", err)
+			}
+		}
+		return nil
+	})
+}
+
+// searchVector is the text entities are searched by, weighted from the name (A)
+// down to the README (D). The simple configuration doesn't stem words, since
+// catalogs mix languages.
+const searchVector = `setweight(to_tsvector('simple', coalesce(metadata_name, '')), 'A') ||
+	setweight(to_tsvector('simple', coalesce(metadata_description, '')), 'B') ||
+	setweight(jsonb_to_tsvector('simple', coalesce(metadata_tags, '[]'::jsonb), '["string"]'), 'B') ||
+	setweight(jsonb_to_tsvector('simple', coalesce(metadata_annotations, '{}'::jsonb), '["string"]'), 'C') ||
+	setweight(to_tsvector('simple', coalesce(spec->>'readmeContent', '')), 'D')`
+
+// MigrateSearch adds the full-text search column and the indexes EntityRepository.Search
+// relies on, including the trigram indexes that make it tolerate typos. It runs after
+// AutoMigrate, which doesn't know about them, and is a no-op once they exist.
+func MigrateSearch(db *gorm.DB) error {
+	statements := []string{
+		`CREATE EXTENSION IF NOT EXISTS pg_trgm`,
+		`ALTER TABLE entities ADD COLUMN IF NOT EXISTS search_vector tsvector GENERATED ALWAYS AS (` + searchVector + `) STORED`,
+		`CREATE INDEX IF NOT EXISTS idx_entities_search_vector ON entities USING gin (search_vector)`,
+		`CREATE INDEX IF NOT EXISTS idx_entities_name_trgm ON entities USING gin (metadata_name gin_trgm_ops)`,
+		`CREATE INDEX IF NOT EXISTS idx_entities_description_trgm ON entities USING gin (metadata_description gin_trgm_ops)`,
+	}
+	for _, statement := range statements {
+		if err := db.Exec(statement).Error; err != nil {
+			return fmt.Errorf("failed to set up search: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package postgres
+
+import (
+	"dev-compass/internal/domain/entities"
+	"gorm.io/gorm"
+)
+
+// RelationRepository is a GORM implementation of the relation repository.
+type RelationRepository struct {
+	db *gorm.DB
+}
+
+// NewRelationRepository creates a new GORM relation repository.
+func NewRelationRepository(db *gorm.DB) *RelationRepository {
+	return &RelationRepository{db: db}
+}
+
+// FindAll retrieves all stored relations.
+func (r *RelationRepository) FindAll() ([]entities.EntityRelation, error) {
+	var relations []entities.EntityRelation
+	if err := r.db.Find(&relations).Error; err != nil {
+		return nil, err
+	}
+	return relations, nil
+}
+
+// FindBySource retrieves the relations going out of an entity, ordered by type and target.
+func (r *RelationRepository) FindBySource(ref string) ([]entities.EntityRelation, error) {
+	var relations []entities.EntityRelation
+	if err := r.db.Where("source_ref = ?", ref).Order("type, target_ref").Find(&relations).Error; err != nil {
+		return nil, err
+	}
+	return relations, nil
+}
+
+// FindByTarget retrieves the relations pointing to an entity, ordered by type and source.
+func (r *RelationRepository) FindByTarget(ref string) ([]entities.EntityRelation, error) {
+	var relations []entities.EntityRelation
+	if err := r.db.Where("target_ref = ?", ref).Order("type, source_ref").Find(&relations).Error; err != nil {
+		return nil, err
+	}
+	return relations, nil
+}
+
+// ReplaceForOrigin replaces every relation declared by the origin entity in a single transaction.
+func (r *RelationRepository) ReplaceForOrigin(origin string, relations []entities.EntityRelation) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("origin_ref = ?", origin).Delete(&entities.EntityRelation{}).Error; err != nil {
+			return err
+		}
+		if len(relations) == 0 {
+			return nil
+		}
+		return tx.Create(&relations).Error
+	})
+}
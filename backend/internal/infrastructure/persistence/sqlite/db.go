@@ -0,0 +1,61 @@
+// Package sqlite stores the catalog in an embedded SQLite database, so DevCompass
+// can run as a single binary without a database server. It behaves as the
+// postgres package does: the PostgreSQL-specific SQL is replaced by the JSON and
+// FTS5 functions of SQLite, and trigram similarity is computed in Go.
+package sqlite
+
+import (
+	"database/sql/driver"
+	"dev-compass/internal/infrastructure/config"
+	"dev-compass/internal/infrastructure/persistence/textsearch"
+	"fmt"
+	gosqlite "github.com/glebarez/go-sqlite"
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func init() {
+	// The pg_trgm functions used by the postgres repositories, registered for every connection.
+	gosqlite.MustRegisterDeterministicScalarFunction("similarity", 2, func(ctx *gosqlite.FunctionContext, args []driver.Value) (driver.Value, error) {
+		return textsearch.Similarity(textArg(args[0]), textArg(args[1])), nil
+	})
+	gosqlite.MustRegisterDeterministicScalarFunction("word_similarity", 2, func(ctx *gosqlite.FunctionContext, args []driver.Value) (driver.Value, error) {
+		return textsearch.WordSimilarity(textArg(args[0]), textArg(args[1])), nil
+	})
+}
+
+// ConnectDB opens the SQLite database file of the configuration, creating it if needed.
+func ConnectDB(config *config.Config) (*gorm.DB, error) {
+	dsn := config.DB.Path + "?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)"
+	conn, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Warn),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open the SQLite database %s: %w", config.DB.Path, err)
+	}
+
+	sqlDB, err := conn.DB()
+	if err != nil {
+		return nil, err
+	}
+	// SQLite allows a single writer. Sharing one connection serializes writes
+	// instead of failing transactions with SQLITE_BUSY, and keeps ":memory:"
+	// databases from being opened once per connection.
+	sqlDB.SetMaxOpenConns(1)
+	return conn, nil
+}
+
+// textArg returns the text of an argument of a SQL function, or "" for NULL.
+func textArg(value driver.Value) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
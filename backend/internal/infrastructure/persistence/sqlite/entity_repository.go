@@ -0,0 +1,289 @@
+package sqlite
+
+import (
+	"dev-compass/internal/domain/entities"
+	"dev-compass/internal/domain/ports"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"strings"
+)
+
+// EntityRepository is a GORM implementation of the entity repository for SQLite.
+type EntityRepository struct {
+	db           *gorm.DB
+	maxRevisions int // Revisions kept per entity; 0 keeps them all
+}
+
+// NewEntityRepository creates a new GORM entity repository that keeps the
+// maxRevisions newest revisions of every entity, or all of them when it is 0.
+func NewEntityRepository(db *gorm.DB, maxRevisions int) *EntityRepository {
+	return &EntityRepository{db: db, maxRevisions: maxRevisions}
+}
+
+// FindAll retrieves all entities.
+func (r *EntityRepository) FindAll() ([]entities.Entity, error) {
+	var entityList []entities.Entity
+	if err := r.db.Find(&entityList).Error; err != nil {
+		return nil, err
+	}
+	return entityList, nil
+}
+
+// sortExpressions maps every sort field to the SQL expression it sorts by.
+var sortExpressions = map[string]string{
+	ports.SortByName:      "COALESCE(metadata_name, '')",
+	ports.SortByNamespace: "COALESCE(metadata_namespace, '')",
+	ports.SortByKind:      "COALESCE(kind, '')",
+	ports.SortByOwner:     "COALESCE(spec ->> '$.owner', '')",
+	ports.SortByLifecycle: "COALESCE(spec ->> '$.lifecycle', '')",
+	ports.SortByType:      "COALESCE(spec ->> '$.type', '')",
+}
+
+// Query returns the page of entities selected by a query, filtering on the JSON
+// columns in the database. Values are compared with the BINARY collation, which
+// orders them as the C collation of the postgres repository does.
+func (r *EntityRepository) Query(query ports.EntityQuery) (*ports.EntityPage, error) {
+	sortField, err := query.SortField()
+	if err != nil {
+		return nil, err
+	}
+	cursor, err := ports.DecodeCursor(query.Cursor, sortField, query.Descending)
+	if err != nil {
+		return nil, err
+	}
+	sortExpression := sortExpressions[sortField]
+
+	page := &ports.EntityPage{Entities: []entities.Entity{}}
+	if err := filterEntities(r.db.Model(&entities.Entity{}), query).Count(&page.TotalCount).Error; err != nil {
+		return nil, err
+	}
+
+	direction, comparison := "ASC", ">"
+	if query.Descending {
+		direction, comparison = "DESC", "<"
+	}
+	tx := filterEntities(r.db.Model(&entities.Entity{}), query).
+		Order(fmt.Sprintf(`%s COLLATE BINARY %s, ref COLLATE BINARY %s`, sortExpression, direction, direction))
+	if cursor != nil {
+		tx = tx.Where(fmt.Sprintf(`(%s COLLATE BINARY, ref COLLATE BINARY) %s (?, ?)`, sortExpression, comparison), cursor.Value, cursor.Ref)
+	}
+	if query.Limit > 0 {
+		// One more entity tells whether there is a next page.
+		tx = tx.Limit(query.Limit + 1)
+	}
+	if err := tx.Find(&page.Entities).Error; err != nil {
+		return nil, err
+	}
+
+	if query.Limit > 0 && len(page.Entities) > query.Limit {
+		page.Entities = page.Entities[:query.Limit]
+		last := page.Entities[query.Limit-1]
+		var value string
+		if err := r.db.Model(&entities.Entity{}).Select(sortExpression).Where("ref = ?", last.Ref).Scan(&value).Error; err != nil {
+			return nil, err
+		}
+		page.NextCursor = ports.PageCursor{Sort: sortField, Descending: query.Descending, Value: value, Ref: last.Ref}.Encode()
+	}
+	return page, nil
+}
+
+// Facets counts the distinct values of each facet across the entities matching a
+// query. Each facet is grouped by the database and sorted with ports.SortFacetCounts.
+func (r *EntityRepository) Facets(query ports.EntityQuery, facets []ports.Facet) (map[string][]ports.FacetCount, error) {
+	result := make(map[string][]ports.FacetCount, len(facets))
+	for _, facet := range facets {
+		tx := filterEntities(r.db.Model(&entities.Entity{}), query.WithoutFilterOn(facet))
+		switch facet.Field {
+		case ports.FacetKind:
+			tx = tx.Select("kind AS value, count(*) AS count").Group("kind")
+		case ports.FacetNamespace:
+			tx = tx.Select("metadata_namespace AS value, count(*) AS count").Group("metadata_namespace")
+		case ports.FacetTags:
+			tx = tx.Joins(`JOIN json_each(CASE WHEN json_type(metadata_tags) = 'array' THEN metadata_tags ELSE '[]' END) AS tag`).
+				Select("CAST(tag.value AS TEXT) AS value, count(DISTINCT ref) AS count").Group("tag.value")
+		case ports.FacetLabel:
+			tx = tx.Select("metadata_labels ->> ? AS value, count(*) AS count", jsonPath(facet.Key)).Group("value")
+		case ports.FacetSpec:
+			tx = tx.Select("spec ->> ? AS value, count(*) AS count", jsonPath(facet.Key)).Group("value")
+		default:
+			return nil, fmt.Errorf("%w: unknown facet %q", ports.ErrInvalidQuery, facet.Name)
+		}
+
+		counts := []ports.FacetCount{}
+		err := r.db.Raw(`SELECT CAST(value AS TEXT) AS value, count FROM (?) AS facet WHERE value IS NOT NULL AND value <> ''`, tx).Scan(&counts).Error
+		if err != nil {
+			return nil, fmt.Errorf("failed to count facet %s: %w", facet.Name, err)
+		}
+		ports.SortFacetCounts(counts)
+		result[facet.Name] = counts
+	}
+	return result, nil
+}
+
+// filterEntities adds the filters of a query to tx.
+func filterEntities(tx *gorm.DB, query ports.EntityQuery) *gorm.DB {
+	if query.Search != "" {
+		// LIKE ignores the case of ASCII letters only, unlike ILIKE.
+		searchTerm := "%" + likeEscaper.Replace(query.Search) + "%"
+		tx = tx.Where(`(metadata_name LIKE ? ESCAPE '\' OR metadata_description LIKE ? ESCAPE '\')`, searchTerm, searchTerm)
+	}
+	if len(query.Kinds) > 0 {
+		tx = tx.Where("lower(kind) IN ?", lowerAll(query.Kinds))
+	}
+	if len(query.Owners) > 0 {
+		tx = tx.Where("lower(spec ->> '$.owner') IN ?", lowerAll(query.Owners))
+	}
+	if len(query.Lifecycles) > 0 {
+		tx = tx.Where("lower(spec ->> '$.lifecycle') IN ?", lowerAll(query.Lifecycles))
+	}
+	if len(query.Types) > 0 {
+		tx = tx.Where("lower(spec ->> '$.type') IN ?", lowerAll(query.Types))
+	}
+	for _, tag := range query.Tags {
+		tx = tx.Where("EXISTS (SELECT 1 FROM json_each(CASE WHEN json_type(metadata_tags) = 'array' THEN metadata_tags ELSE '[]' END) WHERE value = ?)", tag)
+	}
+	for _, requirement := range query.LabelSelector {
+		path := jsonPath(requirement.Key)
+		switch requirement.Operator {
+		case ports.LabelEquals, ports.LabelIn:
+			tx = tx.Where("metadata_labels ->> ? IN ?", path, requirement.Values)
+		case ports.LabelNotEquals, ports.LabelNotIn:
+			tx = tx.Where("(metadata_labels ->> ? IS NULL OR metadata_labels ->> ? NOT IN ?)", path, path, requirement.Values)
+		case ports.LabelExists:
+			tx = tx.Where("metadata_labels ->> ? IS NOT NULL", path)
+		case ports.LabelDoesNotExist:
+			tx = tx.Where("metadata_labels ->> ? IS NULL", path)
+		}
+	}
+	for _, key := range query.Annotations {
+		// json_each compares keys exactly, whatever characters they have.
+		tx = tx.Where("EXISTS (SELECT 1 FROM json_each(CASE WHEN json_type(metadata_annotations) = 'object' THEN metadata_annotations ELSE '{}' END) WHERE key = ?)", key)
+	}
+	return tx
+}
+
+// jsonPath returns the JSON path of a top-level key. Without quotes, SQLite would
+// read the dots of keys such as "app.kubernetes.io/name" as nested objects. SQLite
+// compares the quoted key with the key as it is written in the stored JSON, so it
+// is escaped the same way. Keys with a double quote can't be matched: the path
+// ends at the first one, so ports.ParseLabelSelector rejects them.
+func jsonPath(key string) string {
+	quoted, _ := json.Marshal(key)
+	return "$." + string(quoted)
+}
+
+// likeEscaper escapes the wildcards of a LIKE pattern, so searches match them literally.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+func lowerAll(values []string) []string {
+	lowered := make([]string, len(values))
+	for i, value := range values {
+		lowered[i] = strings.ToLower(value)
+	}
+	return lowered
+}
+
+// FindByRef retrieves a single entity by its canonical reference.
+func (r *EntityRepository) FindByRef(ref string) (*entities.Entity, error) {
+	var entity entities.Entity
+	if err := r.db.First(&entity, "ref = ?", strings.ToLower(ref)).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ports.ErrNotFound
+		}
+		return nil, err
+	}
+	return &entity, nil
+}
+
+// Save creates or updates an entity in the database, recording a revision in the
+// same transaction when its content changed.
+func (r *EntityRepository) Save(entity *entities.Entity) error {
+	entity.Ref = entity.CanonicalRef()
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var latest entities.EntityRevision
+		if err := tx.Where("ref = ?", entity.Ref).Order("revision DESC").Limit(1).Find(&latest).Error; err != nil {
+			return err
+		}
+		if err := tx.Clauses(clause.OnConflict{UpdateAll: true}).Create(entity).Error; err != nil {
+			return err
+		}
+		revision, err := entities.NewEntityRevision(entity, latest.Revision+1)
+		if err != nil {
+			return err
+		}
+		if latest.Revision > 0 && revision.SameContent(&latest) {
+			return nil
+		}
+		if err := tx.Create(revision).Error; err != nil {
+			return err
+		}
+		return r.pruneRevisions(tx, revision)
+	})
+}
+
+// pruneRevisions deletes the revisions of an entity older than the ones kept, latest being the newest.
+func (r *EntityRepository) pruneRevisions(tx *gorm.DB, latest *entities.EntityRevision) error {
+	if r.maxRevisions <= 0 || latest.Revision <= int64(r.maxRevisions) {
+		return nil
+	}
+	return tx.Where("ref = ? AND revision <= ?", latest.Ref, latest.Revision-int64(r.maxRevisions)).Delete(&entities.EntityRevision{}).Error
+}
+
+// UpdateStatus replaces the status and etag of an entity, provided its etag is still etag.
+func (r *EntityRepository) UpdateStatus(ref string, status *entities.EntityStatus, etag, newETag string) error {
+	var statusJSON interface{}
+	if status != nil {
+		data, err := json.Marshal(status)
+		if err != nil {
+			return err
+		}
+		statusJSON = string(data)
+	}
+	result := r.db.Model(&entities.Entity{}).
+		Where("ref = ? AND metadata_e_tag = ?", ref, etag).
+		Updates(map[string]interface{}{"status": statusJSON, "metadata_e_tag": newETag})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected > 0 {
+		return nil
+	}
+	if _, err := r.FindByRef(ref); err != nil {
+		return err
+	}
+	return ports.ErrConflict
+}
+
+// FindRevisions returns the revisions of an entity, newest first, without their snapshot.
+func (r *EntityRepository) FindRevisions(ref string) ([]entities.EntityRevision, error) {
+	revisions := []entities.EntityRevision{}
+	if err := r.db.Omit("entity").Where("ref = ?", strings.ToLower(ref)).Order("revision DESC").Find(&revisions).Error; err != nil {
+		return nil, err
+	}
+	return revisions, nil
+}
+
+// FindRevision returns a single revision of an entity, with its snapshot.
+func (r *EntityRepository) FindRevision(ref string, revision int64) (*entities.EntityRevision, error) {
+	var found entities.EntityRevision
+	if err := r.db.First(&found, "ref = ? AND revision = ?", strings.ToLower(ref), revision).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ports.ErrNotFound
+		}
+		return nil, err
+	}
+	return &found, nil
+}
+
+// Delete removes a single entity by its canonical reference.
+func (r *EntityRepository) Delete(ref string) error {
+	return r.db.Where("ref = ?", strings.ToLower(ref)).Delete(&entities.Entity{}).Error
+}
+
+// DeleteAll removes all records from the entities table.
+func (r *EntityRepository) DeleteAll() error {
+	return r.db.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(&entities.Entity{}).Error
+}
@@ -0,0 +1,119 @@
+package sqlite
+
+import (
+	"dev-compass/internal/domain/entities"
+	"dev-compass/internal/domain/ports"
+	"dev-compass/internal/infrastructure/config"
+	"gorm.io/datatypes"
+	"path/filepath"
+	"testing"
+)
+
+// newTestRepository returns a repository over a new, migrated SQLite database.
+func newTestRepository(t *testing.T, maxRevisions int) *EntityRepository {
+	t.Helper()
+	conn, err := ConnectDB(&config.Config{DB: &config.DB{Driver: config.DriverSQLite, Path: filepath.Join(t.TempDir(), "catalog.db")}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Migrate(conn); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := conn.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return NewEntityRepository(conn, maxRevisions)
+}
+
+func TestSavePrunesOldRevisions(t *testing.T) {
+	tests := []struct {
+		name         string
+		maxRevisions int
+		want         []int64
+	}{
+		{"limited", 3, []int64{5, 4, 3}},
+		{"unlimited", 0, []int64{5, 4, 3, 2, 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newTestRepository(t, tt.maxRevisions)
+			entity := &entities.Entity{Kind: "Component", Metadata: entities.Metadata{Name: "orders", Namespace: entities.DefaultNamespace}, Spec: []byte(`{}`)}
+			for _, description := range []string{"one", "two", "two", "three", "four", "five"} {
+				entity.Metadata.Description = description
+				if err := repo.Save(entity); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			revisions, err := repo.FindRevisions(entity.Ref)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []int64
+			for _, revision := range revisions {
+				got = append(got, revision.Revision)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("revisions = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("revisions = %v, want %v", got, tt.want)
+				}
+			}
+			if _, err := repo.FindRevision(entity.Ref, tt.want[len(tt.want)-1]); err != nil {
+				t.Errorf("oldest kept revision: %v", err)
+			}
+		})
+	}
+}
+
+func TestQueryMatchesKeysWithSpecialCharacters(t *testing.T) {
+	repo := newTestRepository(t, 0)
+	keys := []string{"app.kubernetes.io/name", `team\squad`, "a<b&c>", "ünïcode"}
+	labels := datatypes.JSONMap{}
+	for _, key := range keys {
+		labels[key] = "yes"
+	}
+	entity := &entities.Entity{Kind: "Component", Metadata: entities.Metadata{
+		Name:        "orders",
+		Namespace:   entities.DefaultNamespace,
+		Labels:      labels,
+		Annotations: datatypes.JSONMap{`say "hi"`: "hello"},
+	}, Spec: []byte(`{}`)}
+	if err := repo.Save(entity); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, key := range keys {
+		t.Run(key, func(t *testing.T) {
+			query := ports.EntityQuery{LabelSelector: []ports.LabelRequirement{{Key: key, Operator: ports.LabelEquals, Values: []string{"yes"}}}}
+			page, err := repo.Query(query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if page.TotalCount != 1 {
+				t.Errorf("label %s matches %d entities, want 1", key, page.TotalCount)
+			}
+
+			facet := ports.Facet{Name: "metadata.labels." + key, Field: ports.FacetLabel, Key: key}
+			counts, err := repo.Facets(ports.EntityQuery{}, []ports.Facet{facet})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := counts[facet.Name]; len(got) != 1 || got[0] != (ports.FacetCount{Value: "yes", Count: 1}) {
+				t.Errorf("facet %s = %v, want yes: 1", key, got)
+			}
+		})
+	}
+
+	page, err := repo.Query(ports.EntityQuery{Annotations: []string{`say "hi"`}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page.TotalCount != 1 {
+		t.Errorf("annotation with double quotes matches %d entities, want 1", page.TotalCount)
+	}
+}
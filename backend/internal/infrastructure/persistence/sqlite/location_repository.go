@@ -0,0 +1,49 @@
+package sqlite
+
+import (
+	"dev-compass/internal/domain/entities"
+	"dev-compass/internal/domain/ports"
+	"errors"
+	"gorm.io/gorm"
+)
+
+// LocationRepository is a GORM implementation of the location repository for SQLite.
+type LocationRepository struct {
+	db *gorm.DB
+}
+
+// NewLocationRepository creates a new GORM location repository.
+func NewLocationRepository(db *gorm.DB) *LocationRepository {
+	return &LocationRepository{db: db}
+}
+
+// FindAll retrieves all registered locations, oldest first.
+func (r *LocationRepository) FindAll() ([]entities.Location, error) {
+	var locations []entities.Location
+	if err := r.db.Order("created_at").Find(&locations).Error; err != nil {
+		return nil, err
+	}
+	return locations, nil
+}
+
+// FindByID retrieves a single location by its ID.
+func (r *LocationRepository) FindByID(id string) (*entities.Location, error) {
+	var location entities.Location
+	if err := r.db.First(&location, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ports.ErrNotFound
+		}
+		return nil, err
+	}
+	return &location, nil
+}
+
+// Save creates a location in the database.
+func (r *LocationRepository) Save(location *entities.Location) error {
+	return r.db.Create(location).Error
+}
+
+// Delete removes a single location by its ID.
+func (r *LocationRepository) Delete(id string) error {
+	return r.db.Where("id = ?", id).Delete(&entities.Location{}).Error
+}
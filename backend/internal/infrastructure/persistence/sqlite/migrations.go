@@ -0,0 +1,65 @@
+package sqlite
+
+import (
+	"dev-compass/internal/domain/entities"
+	"fmt"
+	"gorm.io/gorm"
+	"log"
+)
+
+// Migrate creates the tables of every repository and the search index, and
+// migrates them when the models change. The legacy migrations of the postgres
+// package are not needed, since SQLite databases never had the old schemas.
+func Migrate(db *gorm.DB) error {
+	if err := db.AutoMigrate(&entities.Entity{}, &entities.Location{}, &entities.EntityRelation{}, &entities.EntityRevision{}); err != nil {
+		return err
+	}
+	return MigrateSearch(db)
+}
+
+// searchColumns are the texts entities are searched by, from the name down to the
+// README, as in the search vector of the postgres package. new is the entity row.
+const searchColumns = `new.ref,
+	coalesce(new.metadata_name, ''),
+	coalesce(new.metadata_description, ''),
+	coalesce((SELECT group_concat(value, ' ') FROM json_each(CASE WHEN json_valid(new.metadata_tags) THEN new.metadata_tags ELSE '[]' END) WHERE type = 'text'), ''),
+	coalesce((SELECT group_concat(value, ' ') FROM json_each(CASE WHEN json_valid(new.metadata_annotations) THEN new.metadata_annotations ELSE '{}' END) WHERE type = 'text'), ''),
+	coalesce(new.spec ->> '$.readmeContent', '')`
+
+// MigrateSearch adds the FTS5 table EntityRepository.Search relies on, and the
+// triggers that keep it in sync with the entities table. The unicode61 tokenizer
+// keeps diacritics, as the simple text search configuration of PostgreSQL does.
+// Entities stored before the table existed are indexed when it is created.
+func MigrateSearch(db *gorm.DB) error {
+	created := !db.Migrator().HasTable("entities_search")
+	statements := []string{
+		`CREATE VIRTUAL TABLE IF NOT EXISTS entities_search USING fts5(ref UNINDEXED, name, description, tags, annotations, readme, tokenize = 'unicode61 remove_diacritics 0')`,
+		`CREATE TRIGGER IF NOT EXISTS entities_search_insert AFTER INSERT ON entities BEGIN
+			INSERT INTO entities_search (ref, name, description, tags, annotations, readme) VALUES (` + searchColumns + `);
+		END`,
+		`CREATE TRIGGER IF NOT EXISTS entities_search_update AFTER UPDATE ON entities BEGIN
+			DELETE FROM entities_search WHERE ref = old.ref;
+			INSERT INTO entities_search (ref, name, description, tags, annotations, readme) VALUES (` + searchColumns + `);
+		END`,
+		`CREATE TRIGGER IF NOT EXISTS entities_search_delete AFTER DELETE ON entities BEGIN
+			DELETE FROM entities_search WHERE ref = old.ref;
+		END`,
+	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		for _, statement := range statements {
+			if err := tx.Exec(statement).Error; err != nil {
+				return fmt.Errorf("failed to set up search: %w", err)
+			}
+		}
+		if !created {
+			return nil
+		}
+
+		log.Println("INFO: Indexing existing entities for search...")
+		// The trigger refers to the row as new, so existing rows are selected under that name.
+		if err := tx.Exec(`INSERT INTO entities_search (ref, name, description, tags, annotations, readme) SELECT ` + searchColumns + ` FROM entities AS new`).Error; err != nil {
+			return fmt.Errorf("failed to index entities for search: %w", err)
+		}
+		return nil
+	})
+}
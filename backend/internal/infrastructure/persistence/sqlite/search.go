@@ -0,0 +1,119 @@
+package sqlite
+
+import (
+	"dev-compass/internal/domain/entities"
+	"dev-compass/internal/domain/ports"
+	"dev-compass/internal/infrastructure/persistence/textsearch"
+	"regexp"
+	"strings"
+)
+
+// searchRank weights the columns of the search table, mirroring the weights of the
+// postgres search vector. bm25 is lower for better matches, hence the minus sign.
+const searchRank = `-bm25(entities_search, 0, 1.0, 0.4, 0.4, 0.2, 0.1)`
+
+// searchSnippet highlights the description, or the README when the description
+// doesn't match, as ts_headline does with both of them in the postgres repository.
+const searchSnippet = `CASE WHEN instr(snippet(entities_search, 2, '<mark>', '</mark>', ' ... ', 30), '<mark>') > 0
+	THEN snippet(entities_search, 2, '<mark>', '</mark>', ' ... ', 30)
+	ELSE snippet(entities_search, 5, '<mark>', '</mark>', ' ... ', 30) END`
+
+// Search returns the entities matching a full-text search, ranked by how well the
+// search table matches and how close the name is to the text. Names and descriptions
+// within trigram distance of the text match too, which makes typos forgivable.
+func (r *EntityRepository) Search(text string, filters ports.EntityQuery) ([]ports.SearchResult, error) {
+	filters.Search = ""
+	ranked := filterEntities(r.db.Model(&entities.Entity{}), filters)
+
+	matched := "FALSE"
+	rank, snippet := "0", "''"
+	if expression := matchExpression(text); expression != "" {
+		hits := r.db.Table("entities_search").
+			Select("ref, "+searchRank+" AS score, "+searchSnippet+" AS snippet").
+			Where("entities_search MATCH ?", expression)
+		ranked = ranked.Joins("LEFT JOIN (?) AS hits ON hits.ref = entities.ref", hits)
+		matched, rank, snippet = "hits.ref IS NOT NULL", "coalesce(hits.score, 0)", "coalesce(hits.snippet, '')"
+	}
+	ranked = ranked.
+		Select("entities.ref AS ref, "+rank+" + similarity(metadata_name, ?) AS rank, "+snippet+" AS snippet", text).
+		Where("("+matched+" OR word_similarity(?, metadata_name) >= ? OR word_similarity(?, metadata_description) >= ?)",
+			text, textsearch.WordSimilarityThreshold, text, textsearch.WordSimilarityThreshold).
+		Order("rank DESC, entities.ref")
+	if filters.Limit > 0 {
+		ranked = ranked.Limit(filters.Limit)
+	}
+
+	var hits []struct {
+		Ref     string
+		Rank    float64
+		Snippet string
+	}
+	if err := ranked.Scan(&hits).Error; err != nil {
+		return nil, err
+	}
+
+	refs := make([]string, len(hits))
+	for i, hit := range hits {
+		refs[i] = hit.Ref
+	}
+	var found []entities.Entity
+	if len(refs) > 0 {
+		if err := r.db.Where("ref IN ?", refs).Find(&found).Error; err != nil {
+			return nil, err
+		}
+	}
+	byRef := make(map[string]entities.Entity, len(found))
+	for _, entity := range found {
+		byRef[entity.Ref] = entity
+	}
+
+	results := make([]ports.SearchResult, 0, len(hits))
+	for _, hit := range hits {
+		if entity, ok := byRef[hit.Ref]; ok {
+			results = append(results, ports.SearchResult{Entity: entity, Rank: hit.Rank, Snippet: hit.Snippet})
+		}
+	}
+	return results, nil
+}
+
+// searchTokens splits a web search into quoted phrases and words, either of them
+// optionally prefixed with "-".
+var searchTokens = regexp.MustCompile(`-?"[^"]*"?|\S+`)
+
+// matchExpression translates a web search, as websearch_to_tsquery reads it, to
+// an FTS5 query: words must all match, "quoted phrases" match in order, "or"
+// separates alternatives and a leading "-" excludes a word. It returns "" when
+// the text has no words.
+func matchExpression(text string) string {
+	var expression []string
+	or := false
+	for _, token := range searchTokens.FindAllString(text, -1) {
+		if strings.EqualFold(token, "or") {
+			or = len(expression) > 0
+			continue
+		}
+		negated := strings.HasPrefix(token, "-")
+		words := textsearch.Words(strings.TrimPrefix(token, "-"))
+		if len(words) == 0 {
+			continue
+		}
+		// Words only hold letters and digits, so the phrase needs no escaping.
+		phrase := `"` + strings.Join(words, " ") + `"`
+
+		switch {
+		case negated && len(expression) == 0:
+			// FTS5 only excludes words from other matches, so a leading exclusion is dropped.
+			continue
+		case negated:
+			expression = append(expression, "NOT", phrase)
+		case or:
+			expression = append(expression, "OR", phrase)
+		case len(expression) > 0:
+			expression = append(expression, "AND", phrase)
+		default:
+			expression = append(expression, phrase)
+		}
+		or = false
+	}
+	return strings.Join(expression, " ")
+}
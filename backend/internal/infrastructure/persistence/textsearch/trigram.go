@@ -0,0 +1,62 @@
+// Package textsearch approximates the text search functions of PostgreSQL for
+// the repositories of databases that don't have them.
+package textsearch
+
+import (
+	"strings"
+	"unicode"
+)
+
+// WordSimilarityThreshold is the similarity from which a name or description
+// matches, the default of the pg_trgm <% operator.
+const WordSimilarityThreshold = 0.6
+
+// Words splits text into lowercase words, as the simple text search configuration does.
+func Words(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// Similarity computes the similarity of two strings as pg_trgm does: the share
+// of trigrams they have in common.
+func Similarity(a, b string) float64 {
+	return jaccard(trigrams(a), trigrams(b))
+}
+
+// WordSimilarity is the highest trigram similarity between a and either b or any
+// word of b, an approximation of the pg_trgm <% operator.
+func WordSimilarity(a, b string) float64 {
+	target := trigrams(a)
+	best := jaccard(target, trigrams(b))
+	for _, word := range Words(b) {
+		best = max(best, jaccard(target, trigrams(word)))
+	}
+	return best
+}
+
+// trigrams returns the trigrams of every word of a string, each word padded with
+// two spaces in front and one behind.
+func trigrams(s string) map[string]bool {
+	set := make(map[string]bool)
+	for _, word := range Words(s) {
+		padded := []rune("  " + word + " ")
+		for i := 0; i+3 <= len(padded); i++ {
+			set[string(padded[i:i+3])] = true
+		}
+	}
+	return set
+}
+
+func jaccard(a, b map[string]bool) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	shared := 0
+	for trigram := range a {
+		if b[trigram] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(a)+len(b)-shared)
+}